@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"golang.org/x/term"
+)
+
+// appendSeparators joins appended text to the existing content of a field
+var appendSeparators = map[string]string{
+	"infoShort": " ",
+	"infoLong":  "\n\n", // a new paragraph
+	"example":   "\n",
+}
+
+// readInputText returns the text to add: the input piped into recall, so
+// that every argument is part of the key path, or else the last argument.
+// A last argument of "-" reads stdin explicitly.
+func readInputText(args []string) ([]string, string, error) {
+	var text string
+	explicit := len(args) > 1 && args[len(args)-1] == "-"
+	if explicit {
+		args = args[:len(args)-1]
+	}
+	if explicit || !term.IsTerminal(int(os.Stdin.Fd())) {
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not read stdin: %v", err)
+		}
+		text = string(input)
+	}
+	if !explicit && strings.TrimSpace(text) == "" {
+		if len(args) < 2 {
+			return nil, "", fmt.Errorf("nothing to add: pass the text as last argument or pipe it into recall")
+		}
+		text = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	// Keep the indentation of the first line, e.g. of a help text
+	text = strings.TrimRight(strings.TrimLeft(text, "\r\n"), " \t\r\n")
+	if text == "" {
+		return nil, "", fmt.Errorf("nothing to add: the text is empty")
+	}
+	return args, text, nil
+}
+
+// addCommand handles `recall add <project> [key...] [--field f] [--append|--replace] [text|-]`.
+// The text is read from stdin when it is piped or "-" is given, else it's the last argument.
+func addCommand(settings *Settings, args []string) {
+	args, replace := extractFlag(args, "--replace")
+	args, appendMode := extractFlag(args, "--append")
+	args, field, err := extractOption(args, "--field")
+	if field == "" {
+		field = "infoLong"
+	}
+	if err != nil || len(args) == 0 || (replace && appendMode) {
+		fmt.Println("[ERROR] Usage: recall add <project> [key...] [--field <name>] [--append|--replace] [\"text\"|-]")
+		os.Exit(1)
+	}
+	if keyDataField(&KeyData{}, field) == nil {
+		fmt.Printf("[ERROR] Unknown field '%s', use %s\n", field, strings.Join(editSections, ", "))
+		os.Exit(1)
+	}
+
+	args, text, err := readInputText(args)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		os.Exit(1)
+	}
+	project, keyPath := args[0], args[1:]
+
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	projectData := loadProjectData(projectFile)
+
+	path := keyPathString(keyPath)
+	current := getKeyData(projectData, path)
+	_, exists := getKeyNode(projectData, path)
+
+	updated := current
+	value := keyDataField(&updated, field)
+	if replace || strings.TrimSpace(*value) == "" {
+		*value = text
+	} else {
+		*value = strings.TrimRight(*value, " \n") + appendSeparators[field] + text
+	}
+	if updated == current {
+		fmt.Println("[INFO] No changes made")
+		return
+	}
+
+	node := ensureKeyNode(projectData, path)
+	node[field] = *value
+	if err := saveProjectData(projectFile, projectData); err != nil {
+		fmt.Printf("[ERROR] Error saving %s: %v\n", projectFile.Path, err)
+		return
+	}
+	label := strings.Join(keyPath, " → ")
+	if len(keyPath) == 0 {
+		label = "general info"
+	}
+	action := "Appended to"
+	if replace {
+		action = "Replaced"
+	}
+	if !exists {
+		action = "Created key with"
+	}
+	fmt.Printf("[INFO] %s %s of %s in %s\n", action, field, label, projectFile.Path)
+}
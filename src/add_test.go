@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withStdin runs fn with input piped into stdin
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+		r.Close()
+	}()
+	go func() {
+		w.WriteString(input)
+		w.Close()
+	}()
+	fn()
+}
+
+func TestReadInputText(t *testing.T) {
+	tests := []struct {
+		args  []string
+		stdin string
+		key   string
+		text  string
+		err   string
+	}{
+		{args: []string{"app", "db", "text"}, key: "app db", text: "text"},
+		{args: []string{"app", "\n  indented\n\n"}, key: "app", text: "  indented"},
+		{args: []string{"app", "db"}, stdin: "piped\n", key: "app db", text: "piped"},
+		{args: []string{"app", "db", "pool"}, stdin: "\r\n  usage: x\r\n", key: "app db pool", text: "  usage: x"},
+		{args: []string{"app", "db", "-"}, stdin: "explicit\n", key: "app db", text: "explicit"},
+		{args: []string{"app", "db", "text"}, stdin: " \n", key: "app db", text: "text"},
+		{args: []string{"app"}, err: "pass the text as last argument"},
+		{args: []string{"app", "db", " \n"}, err: "the text is empty"},
+		{args: []string{"app", "db", "-"}, stdin: "", err: "the text is empty"},
+	}
+	for _, tt := range tests {
+		var args []string
+		var text string
+		var err error
+		withStdin(t, tt.stdin, func() { args, text, err = readInputText(tt.args) })
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("readInputText(%q) error %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("readInputText(%q): %v", tt.args, err)
+			continue
+		}
+		if strings.Join(args, " ") != tt.key || text != tt.text {
+			t.Errorf("readInputText(%q) = %q, %q, want %s, %q", tt.args, args, text, tt.key, tt.text)
+		}
+	}
+}
+
+func TestAddPipedHelpText(t *testing.T) {
+	useTempStores(t)
+	writeTestFile(t, filepath.Join(localStoreDir, "myApp.yaml"), "info:\n  infoShort: app\n")
+	settings := defaultSettings()
+
+	// some-command --help | recall add myApp tooling cli --field example
+	help := "Usage: some-command [options]\n  --help  Show this help\n"
+	withStdin(t, help, func() {
+		captureOutput(t, func() { addCommand(settings, []string{"myApp", "tooling", "cli", "--field", "example"}) })
+	})
+
+	projectFile, err := findProjectFile(settings, "myApp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectData := loadProjectData(projectFile)
+	if data := getKeyData(projectData, "tooling.keys.cli"); data.Example != strings.TrimSpace(help) {
+		t.Errorf("example of tooling → cli = %q, want the piped help text", data.Example)
+	}
+	if data := getKeyData(projectData, "tooling"); data.Example != "" {
+		t.Errorf("example of tooling = %q, want it untouched", data.Example)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// extractFlag removes every occurrence of a boolean flag such as --dry-run
+// from args and reports whether it was present
+func extractFlag(args []string, name string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == name {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// extractOption removes an option with a value, given as "--name value" or
+// "--name=value", from args. It returns an empty value if it wasn't present.
+func extractOption(args []string, name string) ([]string, string, error) {
+	rest := make([]string, 0, len(args))
+	value := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == name:
+			if i+1 >= len(args) {
+				return nil, "", fmt.Errorf("%s requires a value", name)
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, name+"="):
+			value = strings.TrimPrefix(arg, name+"=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, value, nil
+}
+
+// extractOptionValues is like extractOption for options that may be given
+// several times, such as --var, and returns every value in order
+func extractOptionValues(args []string, name string) ([]string, []string, error) {
+	rest := make([]string, 0, len(args))
+	var values []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == name:
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("%s requires a value", name)
+			}
+			values = append(values, args[i+1])
+			i++
+		case strings.HasPrefix(arg, name+"="):
+			values = append(values, strings.TrimPrefix(arg, name+"="))
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, values, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractOptionValues(t *testing.T) {
+	tests := []struct {
+		args   []string
+		rest   []string
+		values []string
+		err    bool
+	}{
+		{[]string{"app", "deploy"}, []string{"app", "deploy"}, nil, false},
+		{[]string{"app", "--var", "host=a", "deploy", "--var=user=b"}, []string{"app", "deploy"}, []string{"host=a", "user=b"}, false},
+		{[]string{"--var", "--yes", "app"}, []string{"app"}, []string{"--yes"}, false},
+		{[]string{"--var="}, []string{}, []string{""}, false},
+		{[]string{"--variable", "x"}, []string{"--variable", "x"}, nil, false},
+		{[]string{"app", "--var"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		rest, values, err := extractOptionValues(tt.args, "--var")
+		if (err != nil) != tt.err {
+			t.Errorf("extractOptionValues(%q) error %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(rest, tt.rest) || !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("extractOptionValues(%q) = %q, %q, want %q, %q", tt.args, rest, values, tt.rest, tt.values)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const bashCompletion = `# bash completion for recall, load with: source <(recall completion bash)
+_recall() {
+    local IFS=$'\n'
+    COMPREPLY=($(recall __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -F _recall recall
+`
+
+const zshCompletion = `#compdef recall
+# zsh completion for recall, load with: source <(recall completion zsh)
+_recall() {
+    local -a candidates
+    candidates=(${(f)"$(recall __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -- $candidates
+}
+compdef _recall recall
+`
+
+const fishCompletion = `# fish completion for recall, load with: recall completion fish | source
+function __recall_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    recall __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c recall -f -a '(__recall_complete)'
+`
+
+// completionScripts are printed by `recall completion <shell>`
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+// commandOptions are completed as the first word if it starts with '-'
+var commandOptions = []string{
+	"--edit", "--history", "--init", "--init-global", "--interactive", "--list", "--log",
+	"--migrate", "--search", "--source", "--stale", "--undo", "--version", "-i",
+}
+
+// keyCommands take a project and a key path
+var keyCommands = map[string]bool{
+	"--edit": true, "--history": true, "--log": true, "--source": true, "add": true, "log": true, "run": true,
+}
+
+// projectCommands take only a project
+var projectCommands = map[string]bool{
+	"-i": true, "--interactive": true, "--stale": true, "export": true,
+}
+
+// valueOptions are followed by a value that is not part of a key path
+var valueOptions = map[string]bool{
+	"--field": true, "--file": true, "--symbol": true, "--format": true, "--output": true, "--var": true,
+}
+
+// completionCommand prints the completion script of a shell
+func completionCommand(args []string) {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		fmt.Println("[ERROR] Usage: recall completion bash|zsh|fish")
+		os.Exit(1)
+	}
+	fmt.Print(completionScripts[args[0]])
+}
+
+// completeCommand is called by the completion scripts with the words after
+// `recall`, the last being the word to complete, and prints the candidates
+func completeCommand(settings *Settings, words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	for _, candidate := range completions(settings, words[:len(words)-1], current) {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+}
+
+func completions(settings *Settings, prior []string, current string) []string {
+	if len(prior) > 0 && prior[len(prior)-1] == "--field" {
+		return editSections
+	}
+	if len(prior) == 0 {
+		if strings.HasPrefix(current, "-") {
+			return commandOptions
+		}
+		// Subcommands are completed besides the project names
+		return append(append([]string{}, subcommands...), completionProjects(settings)...)
+	}
+
+	command := prior[0]
+	rest := keyPathWords(prior[1:])
+	switch {
+	case command == "completion":
+		if len(rest) == 0 {
+			return []string{"bash", "fish", "zsh"}
+		}
+		return nil
+	case command == "import":
+		if len(rest) == 0 {
+			return []string{"go", "markdown"}
+		}
+		return nil
+	case projectCommands[command]:
+		if len(rest) == 0 {
+			return completionProjects(settings)
+		}
+		return nil
+	case keyCommands[command]:
+		if len(rest) == 0 {
+			return completionProjects(settings)
+		}
+		return completionKeys(settings, rest[0], rest[1:])
+	case strings.HasPrefix(command, "-") || command == "sync" || command == "merge-driver":
+		return nil
+	}
+
+	// recall <project> <key>...
+	if strings.HasPrefix(current, "-") {
+		return []string{"--all", "--edit", "--field", "--raw"}
+	}
+	return completionKeys(settings, command, keyPathWords(prior[1:]))
+}
+
+// keyPathWords drops options and their values from words
+func keyPathWords(words []string) []string {
+	var keyPath []string
+	for i := 0; i < len(words); i++ {
+		switch {
+		case valueOptions[words[i]]:
+			i++
+		case strings.HasPrefix(words[i], "-"):
+		default:
+			keyPath = append(keyPath, words[i])
+		}
+	}
+	return keyPath
+}
+
+// completionProjects returns the projects of every store
+func completionProjects(settings *Settings) []string {
+	seen := make(map[string]bool)
+	var projects []string
+	for _, dir := range storeDirs() {
+		names, err := listProjects(settings, dir, "")
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				projects = append(projects, name)
+			}
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// completionKeys returns the names of the sub-keys below keyPath
+func completionKeys(settings *Settings, project string, keyPath []string) []string {
+	location, err := findProjectFile(settings, project)
+	if err != nil {
+		return nil
+	}
+	projectData, err := location.Backend.load(location)
+	if err != nil {
+		return nil
+	}
+
+	var keys map[string]interface{}
+	if len(keyPath) == 0 {
+		keys = make(map[string]interface{})
+		for k, v := range projectData {
+			if k != "info" && k != "include" {
+				keys[k] = v
+			}
+		}
+	} else {
+		node, ok := getKeyNode(projectData, keyPathString(keyPath))
+		if !ok {
+			return nil
+		}
+		keys, _ = toStringMap(node["keys"])
+	}
+
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
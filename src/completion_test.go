@@ -0,0 +1,112 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// mainCommands returns the first words main.go dispatches on
+func mainCommands(t *testing.T) map[string]bool {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isFirstArg := func(expr ast.Expr) bool {
+		index, ok := expr.(*ast.IndexExpr)
+		if !ok {
+			return false
+		}
+		name, _ := index.X.(*ast.Ident)
+		lit, _ := index.Index.(*ast.BasicLit)
+		return name != nil && name.Name == "args" && lit != nil && lit.Value == "0"
+	}
+	commands := make(map[string]bool)
+	addLiteral := func(expr ast.Expr) {
+		if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if command, err := strconv.Unquote(lit.Value); err == nil && !strings.HasPrefix(command, "__") {
+				commands[command] = true
+			}
+		}
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SwitchStmt:
+			if n.Tag != nil && isFirstArg(n.Tag) {
+				for _, stmt := range n.Body.List {
+					for _, expr := range stmt.(*ast.CaseClause).List {
+						addLiteral(expr)
+					}
+				}
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.EQL && isFirstArg(n.X) {
+				addLiteral(n.Y)
+			}
+		}
+		return true
+	})
+	return commands
+}
+
+func TestCompletionCoversEveryCommand(t *testing.T) {
+	var options, commands []string
+	for command := range mainCommands(t) {
+		if strings.HasPrefix(command, "-") {
+			options = append(options, command)
+		} else {
+			commands = append(commands, command)
+		}
+	}
+	for _, list := range [][]string{options, commands} {
+		sort.Strings(list)
+	}
+	wantOptions := append([]string{}, commandOptions...)
+	sort.Strings(wantOptions)
+	if strings.Join(options, " ") != strings.Join(wantOptions, " ") {
+		t.Errorf("main.go handles the options %v, completion offers %v", options, wantOptions)
+	}
+	if strings.Join(commands, " ") != strings.Join(subcommands, " ") {
+		t.Errorf("main.go handles the subcommands %v, subcommands lists %v", commands, subcommands)
+	}
+
+	for _, command := range subcommands {
+		if err := validateProjectName(command); err == nil {
+			t.Errorf("subcommand %s can be used as a project name", command)
+		}
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	useTempStores(t)
+	writeTestFile(t, localStoreDir+"/app.yaml", "db:\n  infoShort: d\n  keys:\n    pool:\n      infoShort: p\n")
+	settings := defaultSettings()
+
+	tests := []struct {
+		prior   []string
+		current string
+		want    string
+	}{
+		{nil, "--inter", "--interactive"},
+		{nil, "lo", "log"},
+		{nil, "ap", "app"},
+		{[]string{"log"}, "", "app"},
+		{[]string{"log", "app"}, "", "db"},
+		{[]string{"app", "db"}, "", "pool"},
+		{[]string{"app", "db", "--field"}, "ex", "example"},
+	}
+	for _, tt := range tests {
+		found := false
+		for _, candidate := range completions(settings, tt.prior, tt.current) {
+			found = found || candidate == tt.want
+		}
+		if !found {
+			t.Errorf("completions(%q, %q) don't offer %s", tt.prior, tt.current, tt.want)
+		}
+	}
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"io/ioutil"
+	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -31,24 +32,13 @@ func getKeyData(projectData ProjectData, keyPath string) KeyData {
 		if value, exists := current[key]; exists {
 			if i == len(keyParts)-1 {
 				// This is the final key
-				if keyMap, ok := value.(map[interface{}]interface{}); ok {
-					return KeyData{
-						InfoShort: getStringValue(keyMap, "infoShort"),
-						InfoLong:  getStringValue(keyMap, "infoLong"),
-						Example:   getStringValue(keyMap, "example"),
-					}
+				if keyMap, ok := toStringMap(value); ok {
+					return keyDataFromNode(keyMap)
 				}
 			} else {
 				// Navigate deeper
-				if nextMap, ok := value.(map[interface{}]interface{}); ok {
-					// Convert to ProjectData format
-					converted := make(ProjectData)
-					for k, v := range nextMap {
-						if strKey, ok := k.(string); ok {
-							converted[strKey] = v
-						}
-					}
-					current = converted
+				if nextMap, ok := toStringMap(value); ok {
+					current = nextMap
 				} else {
 					break
 				}
@@ -61,15 +51,6 @@ func getKeyData(projectData ProjectData, keyPath string) KeyData {
 	return KeyData{} // Return empty if key doesn't exist
 }
 
-func getStringValue(m map[interface{}]interface{}, key string) string {
-	if value, exists := m[key]; exists {
-		if str, ok := value.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
-
 func setKeyData(projectData ProjectData, keyPath string, data KeyData) {
 	// keyPath is a string representing the path to the key, e.g. "key1.keys.key2"
 	// If keyPath is empty, use "info" for root-level project information
@@ -152,13 +133,8 @@ func setKeyData(projectData ProjectData, keyPath string, data KeyData) {
 	}
 }
 
-func parseEditedFile(filename string) (KeyData, error) {
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return KeyData{}, err
-	}
-	
-	content := string(data)
+// parseEditedText reads the sections of the text edit template
+func parseEditedText(content string) KeyData {
 	lines := strings.Split(content, "\n")
 	
 	var keyData KeyData
@@ -198,7 +174,95 @@ func parseEditedFile(filename string) (KeyData, error) {
 		setSection(&keyData, currentSection, strings.TrimSpace(strings.Join(currentContent, "\n")))
 	}
 	
-	return keyData, nil
+	return keyData
+}
+
+// editSections are the section headers of the edit file, in order
+var editSections = []string{"infoShort", "infoLong", "example"}
+
+// keyDataField returns the field of data with the given name, or nil
+func keyDataField(data *KeyData, name string) *string {
+	switch name {
+	case "infoShort":
+		return &data.InfoShort
+	case "infoLong":
+		return &data.InfoLong
+	case "example":
+		return &data.Example
+	}
+	return nil
+}
+
+// validateEditedText checks that an edited document can be parsed without
+// losing a field, and describes every problem it finds. Only the lines
+// parseEditedText takes as section headers are checked; the content of a
+// section may contain anything, e.g. "example: see below".
+func validateEditedText(content string) []string {
+	var problems []string
+	seen := make(map[string]int)
+	inSection := false
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		isHeader := false
+		for _, section := range editSections {
+			if trimmed == section+":" {
+				seen[section]++
+				if seen[section] == 2 {
+					problems = append(problems, fmt.Sprintf("line %d: section '%s:' appears more than once", i+1, section))
+				}
+				isHeader = true
+			}
+		}
+		if isHeader {
+			inSection = true
+		} else if !inSection && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			problems = append(problems, fmt.Sprintf("line %d: text before the first section header would be lost", i+1))
+		}
+	}
+	for _, section := range editSections {
+		if seen[section] == 0 {
+			problems = append(problems, fmt.Sprintf("section header '%s:' is missing, the field would be lost", section))
+		}
+	}
+	return problems
+}
+
+// editedTextEmpty reports whether an edited document holds nothing but
+// blank and comment lines, which aborts the edit
+func editedTextEmpty(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// annotateEditedText replaces the comment lines at the top of an edited
+// document with a description of its problems, like git rebase -i does
+func annotateEditedText(content string, problems []string) string {
+	lines := strings.Split(content, "\n")
+	for len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "#") {
+		lines = lines[1:]
+	}
+
+	var header []string
+	header = append(header, "# The edited entry could not be saved:")
+	for _, problem := range problems {
+		header = append(header, "#   "+problem)
+	}
+	header = append(header, "# Fix the problems and save again, or empty the file to abort.", "#")
+	return strings.Join(append(header, lines...), "\n")
+}
+
+// trimKeyData trims the fields the way the edit templates do, so stored and
+// edited data can be compared
+func trimKeyData(data KeyData) KeyData {
+	return KeyData{
+		InfoShort: strings.TrimSpace(data.InfoShort),
+		InfoLong:  strings.TrimSpace(data.InfoLong),
+		Example:   strings.TrimSpace(data.Example),
+	}
 }
 
 func setSection(keyData *KeyData, section, content string) {
@@ -211,3 +275,173 @@ func setSection(keyData *KeyData, section, content string) {
 		keyData.Example = content
 	}
 }
+
+// toStringMap converts the map types produced by YAML parsing into a map[string]interface{}
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case ProjectData:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{})
+		for k, val := range v {
+			if strKey, ok := k.(string); ok {
+				converted[strKey] = val
+			}
+		}
+		return converted, true
+	}
+	return nil, false
+}
+
+// keyDataFromNode extracts the info fields of a single key node
+func keyDataFromNode(node map[string]interface{}) KeyData {
+	var data KeyData
+	data.InfoShort, _ = node["infoShort"].(string)
+	data.InfoLong, _ = node["infoLong"].(string)
+	data.Example, _ = node["example"].(string)
+	return data
+}
+
+// walkKeys calls fn for the project info (empty keyPath) and then for every
+// key of the project, depth first and in alphabetical order
+func walkKeys(projectData ProjectData, fn func(keyPath []string, data KeyData)) {
+	walkNodes(projectData, func(keyPath []string, node map[string]interface{}) {
+		fn(keyPath, keyDataFromNode(node))
+	})
+}
+
+// walkNodes is like walkKeys but hands fn the raw key node, including any
+// fields besides the info fields
+func walkNodes(projectData ProjectData, fn func(keyPath []string, node map[string]interface{})) {
+	if info, ok := toStringMap(projectData["info"]); ok {
+		fn([]string{}, info)
+	}
+
+	root := make(map[string]interface{})
+	for k, v := range projectData {
+		if k != "info" && k != "include" {
+			root[k] = v
+		}
+	}
+	walkNodeMap(root, []string{}, fn)
+}
+
+func walkNodeMap(keys map[string]interface{}, parent []string, fn func(keyPath []string, node map[string]interface{})) {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		node, ok := toStringMap(keys[name])
+		if !ok {
+			continue
+		}
+		keyPath := append(append([]string{}, parent...), name)
+		fn(keyPath, node)
+
+		if subKeys, ok := toStringMap(node["keys"]); ok {
+			walkNodeMap(subKeys, keyPath, fn)
+		}
+	}
+}
+
+// keyPathString converts command line key segments into the path used by
+// getKeyData and setKeyData, e.g. ["foo", "bar"] becomes "foo.keys.bar"
+func keyPathString(keyPath []string) string {
+	return strings.Join(keyPath, ".keys.")
+}
+
+// printKeyDataDiff prints the fields that differ between old and new, with
+// removed lines prefixed by '-' and added lines by '+'
+func printKeyDataDiff(old, new KeyData) {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"infoShort", old.InfoShort, new.InfoShort},
+		{"infoLong", old.InfoLong, new.InfoLong},
+		{"example", old.Example, new.Example},
+	}
+	for _, field := range fields {
+		if field.old == field.new {
+			continue
+		}
+		fmt.Printf("  %s:\n", field.name)
+		if field.old != "" {
+			for _, line := range strings.Split(field.old, "\n") {
+				fmt.Printf("\033[31m  - %s\033[0m\n", line)
+			}
+		}
+		if field.new != "" {
+			for _, line := range strings.Split(field.new, "\n") {
+				fmt.Printf("\033[32m  + %s\033[0m\n", line)
+			}
+		}
+	}
+}
+
+// getKeyNode returns the raw node stored at keyPath ("foo.keys.bar"), including
+// fields besides the info fields, and whether it exists
+func getKeyNode(projectData ProjectData, keyPath string) (map[string]interface{}, bool) {
+	if keyPath == "" {
+		keyPath = "info"
+	}
+
+	current := map[string]interface{}(projectData)
+	keyParts := strings.Split(keyPath, ".")
+	for i, key := range keyParts {
+		node, ok := toStringMap(current[key])
+		if !ok {
+			return nil, false
+		}
+		if i == len(keyParts)-1 {
+			return node, true
+		}
+		current = node
+	}
+	return nil, false
+}
+
+// ensureKeyNode returns the node stored at keyPath ("foo.keys.bar") for
+// modification, creating it and its parents if needed
+func ensureKeyNode(projectData ProjectData, keyPath string) map[string]interface{} {
+	if keyPath == "" {
+		keyPath = "info"
+	}
+
+	current := map[string]interface{}(projectData)
+	for _, key := range strings.Split(keyPath, ".") {
+		node, ok := toStringMap(current[key])
+		if !ok {
+			node = make(map[string]interface{})
+		}
+		// toStringMap copies nodes parsed from YAML, store the copy so changes stick
+		current[key] = node
+		current = node
+	}
+	return current
+}
+
+// normalizeValue recursively converts the map types produced by YAML parsing
+// into map[string]interface{}, so values can be compared or encoded as JSON
+func normalizeValue(value interface{}) interface{} {
+	if m, ok := toStringMap(value); ok {
+		converted := make(map[string]interface{})
+		for k, v := range m {
+			converted[k] = normalizeValue(v)
+		}
+		return converted
+	}
+	if list, ok := value.([]interface{}); ok {
+		converted := make([]interface{}, len(list))
+		for i, v := range list {
+			converted[i] = normalizeValue(v)
+		}
+		return converted
+	}
+	return value
+}
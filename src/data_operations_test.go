@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateEditedText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		problems []string
+	}{
+		{
+			name:    "valid",
+			content: "# comment\ninfoShort:\nshort\n\ninfoLong:\nlong\n\nexample:\nx\n",
+		},
+		{
+			name:    "field names in the content",
+			content: "infoShort:\nexample: see below\n\ninfoLong:\nInfoShort:\ninfoLong: is the long text\n\nexample:\nEXAMPLE:\n",
+		},
+		{
+			name:     "text before the first header",
+			content:  "lost\ninfoShort:\n\ninfoLong:\n\nexample:\n",
+			problems: []string{"line 1: text before the first section header would be lost"},
+		},
+		{
+			name:     "header twice",
+			content:  "infoShort:\n\ninfoLong:\n\nexample:\n\n  example:\n",
+			problems: []string{"line 7: section 'example:' appears more than once"},
+		},
+		{
+			name:     "header missing",
+			content:  "infoShort:\nshort\nexample:\n",
+			problems: []string{"section header 'infoLong:' is missing, the field would be lost"},
+		},
+	}
+	for _, tt := range tests {
+		if got := validateEditedText(tt.content); strings.Join(got, "\n") != strings.Join(tt.problems, "\n") {
+			t.Errorf("%s: problems %q, want %q", tt.name, got, tt.problems)
+		}
+	}
+
+	data := parseEditedText(tests[1].content)
+	if data.InfoShort != "example: see below" || data.InfoLong != "InfoShort:\ninfoLong: is the long text" || data.Example != "EXAMPLE:" {
+		t.Errorf("parsed %+v", data)
+	}
+}
+
+func TestEmptyEdit(t *testing.T) {
+	annotated := annotateEditedText("infoShort:\n", []string{"problem"})
+	tests := []struct {
+		template editTemplate
+		content  string
+		want     bool
+	}{
+		{textTemplate{}, "", true},
+		{textTemplate{}, "# only\n  # comments\n\n", true},
+		{textTemplate{}, annotated[:strings.Index(annotated, "infoShort:")], true},
+		{textTemplate{}, annotated, false},
+		{yamlTemplate{}, "# comment\n", true},
+		{yamlTemplate{}, "infoShort: |-\n", false},
+		{markdownTemplate{}, markdownTemplate{}.annotate("", []string{"problem"}), true},
+		{markdownTemplate{}, "# Heading\n", false},
+	}
+	for _, tt := range tests {
+		if got := tt.template.empty(tt.content); got != tt.want {
+			t.Errorf("%T.empty(%q) = %v, want %v", tt.template, tt.content, got, tt.want)
+		}
+	}
+}
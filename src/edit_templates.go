@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"gopkg.in/yaml.v2"
+)
+
+// editTemplate is a format of the temporary file opened by --edit
+type editTemplate interface {
+	// extension is the file extension, which selects the syntax highlighting of editors
+	extension() string
+	// render formats data and returns the line of each field's content
+	render(data KeyData, language string) (string, map[string]int)
+	// parse reads an edited file, or describes why it can't without losing a field
+	parse(content string) (KeyData, []string)
+	// annotate puts problems as comments at the top of an edited file
+	annotate(content string, problems []string) string
+	// empty reports whether an edited file holds nothing but comments, which aborts the edit
+	empty(content string) bool
+}
+
+// editTemplates are the templates selectable with editTemplate in settings.yaml
+var editTemplates = map[string]editTemplate{
+	"text":     textTemplate{},
+	"yaml":     yamlTemplate{},
+	"markdown": markdownTemplate{},
+}
+
+// sourceLanguages maps source file extensions to the language of Markdown fences
+var sourceLanguages = map[string]string{
+	".c": "c", ".h": "c", ".cpp": "cpp", ".cs": "csharp", ".go": "go", ".java": "java",
+	".js": "javascript", ".json": "json", ".py": "python", ".rb": "ruby", ".rs": "rust",
+	".sh": "sh", ".sql": "sql", ".ts": "typescript", ".yaml": "yaml", ".yml": "yaml",
+}
+
+// settingsEditTemplate returns the edit template selected in settings
+func settingsEditTemplate(settings *Settings) (editTemplate, error) {
+	name := settings.EditTemplate
+	if name == "" {
+		name = "text"
+	}
+	template, ok := editTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown edit template '%s' in settings.yaml, use text, yaml or markdown", name)
+	}
+	return template, nil
+}
+
+// exampleLanguage returns the language of the example: that of the key's
+// source file if it references one, else the exampleLanguage setting
+func exampleLanguage(settings *Settings, projectData ProjectData, path string) string {
+	if node, ok := getKeyNode(projectData, path); ok {
+		if source, ok := readKeySource(node); ok {
+			if language, ok := sourceLanguages[strings.ToLower(filepath.Ext(source.File))]; ok {
+				return language
+			}
+		}
+	}
+	return settings.ExampleLanguage
+}
+
+// textTemplate is the original format with a header line per section
+type textTemplate struct{}
+
+func (textTemplate) extension() string { return ".txt" }
+
+func (textTemplate) render(data KeyData, language string) (string, map[string]int) {
+	var lines []string
+	fieldLines := make(map[string]int)
+	for _, name := range editSections {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, name+":")
+		fieldLines[name] = len(lines) + 1
+		lines = append(lines, strings.Split(*keyDataField(&data, name), "\n")...)
+	}
+	return strings.Join(lines, "\n") + "\n", fieldLines
+}
+
+func (textTemplate) parse(content string) (KeyData, []string) {
+	if problems := validateEditedText(content); len(problems) > 0 {
+		return KeyData{}, problems
+	}
+	return parseEditedText(content), nil
+}
+
+func (textTemplate) annotate(content string, problems []string) string {
+	return annotateEditedText(content, problems)
+}
+
+func (textTemplate) empty(content string) bool { return editedTextEmpty(content) }
+
+// yamlTemplate is a YAML document with a block scalar per field
+type yamlTemplate struct{}
+
+func (yamlTemplate) extension() string { return ".yaml" }
+
+func (yamlTemplate) render(data KeyData, language string) (string, map[string]int) {
+	var lines []string
+	fieldLines := make(map[string]int)
+	for _, name := range editSections {
+		value := *keyDataField(&data, name)
+
+		lines = append(lines, name+": "+yamlBlockIndicator(value))
+		fieldLines[name] = len(lines) + 1
+		if value == "" {
+			lines = append(lines, "  ")
+			continue
+		}
+		for _, line := range strings.Split(value, "\n") {
+			if line == "" {
+				lines = append(lines, "")
+			} else {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", fieldLines
+}
+
+// yamlBlockIndicator returns the block scalar header for value. YAML detects
+// the indentation from the first line that isn't blank, so it is given
+// explicitly if that line, or a blank line before it, starts with a space.
+func yamlBlockIndicator(value string) string {
+	for _, line := range strings.Split(value, "\n") {
+		if strings.HasPrefix(line, " ") {
+			return "|2-"
+		}
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+	return "|-"
+}
+
+func (yamlTemplate) parse(content string) (KeyData, []string) {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &fields); err != nil {
+		return KeyData{}, []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+
+	var data KeyData
+	var problems []string
+	for name, value := range fields {
+		field := keyDataField(&data, name)
+		if field == nil {
+			problems = append(problems, fmt.Sprintf("'%s' is not a field, use %s", name, strings.Join(editSections, ", ")))
+			continue
+		}
+		switch v := value.(type) {
+		case nil:
+		case string:
+			*field = strings.TrimSpace(v)
+		case map[interface{}]interface{}, []interface{}:
+			problems = append(problems, fmt.Sprintf("field '%s' must be text, use a block scalar ('%s: |-')", name, name))
+		default:
+			*field = fmt.Sprint(v)
+		}
+	}
+	for _, name := range editSections {
+		if _, ok := fields[name]; !ok {
+			problems = append(problems, fmt.Sprintf("field '%s:' is missing, its content would be lost", name))
+		}
+	}
+	return data, problems
+}
+
+func (yamlTemplate) annotate(content string, problems []string) string {
+	return annotateEditedText(content, problems)
+}
+
+func (yamlTemplate) empty(content string) bool { return editedTextEmpty(content) }
+
+// markdownTemplate is laid out like the files of the markdown storage backend:
+// infoShort in the front matter, infoLong as body and a fenced example
+type markdownTemplate struct{}
+
+func (markdownTemplate) extension() string { return ".md" }
+
+func (markdownTemplate) render(data KeyData, language string) (string, map[string]int) {
+	frontMatter, _ := yaml.Marshal(map[string]string{"infoShort": data.InfoShort})
+	lines := []string{"---"}
+	lines = append(lines, strings.Split(strings.TrimRight(string(frontMatter), "\n"), "\n")...)
+	lines = append(lines, "---", "")
+
+	fieldLines := map[string]int{"infoShort": 2}
+	fieldLines["infoLong"] = len(lines) + 1
+	lines = append(lines, strings.Split(data.InfoLong, "\n")...)
+	lines = append(lines, "", markdownExampleHeading, "")
+
+	fence := markdownFence(data.Example)
+	lines = append(lines, fence+language)
+	fieldLines["example"] = len(lines) + 1
+	lines = append(lines, strings.Split(data.Example, "\n")...)
+	lines = append(lines, fence)
+	return strings.Join(lines, "\n") + "\n", fieldLines
+}
+
+func (markdownTemplate) parse(content string) (KeyData, []string) {
+	content = strings.TrimLeft(stripMarkdownAnnotation(strings.ReplaceAll(content, "\r\n", "\n")), "\n")
+
+	var data KeyData
+	var problems []string
+	if !strings.HasPrefix(content, "---\n") {
+		return data, []string{"the front matter with infoShort is missing, it must start the file with '---'"}
+	}
+	end := strings.Index(content[4:], "\n---\n")
+	if end < 0 {
+		return data, []string{"the front matter is not terminated with '---'"}
+	}
+	var frontMatter map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content[4:4+end]), &frontMatter); err != nil {
+		return data, []string{"front matter: " + strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	for name, value := range frontMatter {
+		if name != "infoShort" {
+			problems = append(problems, fmt.Sprintf("'%s' is not a front matter field, only infoShort is", name))
+			continue
+		}
+		if value != nil {
+			data.InfoShort = strings.TrimSpace(fmt.Sprint(value))
+		}
+	}
+	if _, ok := frontMatter["infoShort"]; !ok {
+		problems = append(problems, "field 'infoShort:' is missing from the front matter, its content would be lost")
+	}
+
+	// Like in the markdown backend, the example follows the last heading
+	// outside a fence, so infoLong may contain the heading itself
+	body := strings.Split(content[4+end+5:], "\n")
+	split := markdownExampleIndex(body)
+	if split < 0 {
+		problems = append(problems, fmt.Sprintf("heading '%s' is missing, the example would be lost", markdownExampleHeading))
+		return data, problems
+	}
+	data.InfoLong = strings.TrimSpace(strings.Join(body[:split], "\n"))
+	data.Example = strings.TrimSpace(parseMarkdownFence(strings.Join(body[split+1:], "\n")))
+	return data, problems
+}
+
+// annotate uses an HTML comment, since # starts a heading in Markdown
+func (markdownTemplate) annotate(content string, problems []string) string {
+	lines := []string{"<!-- The edited entry could not be saved:"}
+	for _, problem := range problems {
+		lines = append(lines, "  "+problem)
+	}
+	lines = append(lines, "Fix the problems and save again, or empty the file to abort. -->", "")
+	return strings.Join(lines, "\n") + stripMarkdownAnnotation(content)
+}
+
+// empty ignores the comment added by annotate; # starts a heading, not a comment
+func (markdownTemplate) empty(content string) bool {
+	return strings.TrimSpace(stripMarkdownAnnotation(strings.TrimSpace(content))) == ""
+}
+
+// stripMarkdownAnnotation removes a comment added by annotate
+func stripMarkdownAnnotation(content string) string {
+	if !strings.HasPrefix(content, "<!-- The edited entry") {
+		return content
+	}
+	end := strings.Index(content, "-->")
+	if end < 0 {
+		return content
+	}
+	return strings.TrimLeft(content[end+len("-->"):], "\n")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var editTemplateTestData = []struct {
+	data KeyData
+	text bool // whether the text template can hold it
+}{
+	{KeyData{InfoShort: "short", InfoLong: "long", Example: "example"}, true},
+	{KeyData{}, true},
+	{KeyData{InfoShort: "a: b", InfoLong: "## Example\n\nThe heading above is part of the text", Example: "```go\nx := 1\n```\n## Example"}, true},
+	{KeyData{InfoLong: "first\n\n  indented", Example: "\n    indented after an empty line\n  less indented"}, true},
+	{KeyData{InfoLong: "  \n  indented after a blank line", Example: "  indented\nnot indented"}, true},
+	{KeyData{InfoShort: "example:", InfoLong: "infoShort: not a field\n---\nexample: |-", Example: "# comment\n---"}, false},
+}
+
+func TestEditTemplatesRoundTrip(t *testing.T) {
+	for _, name := range []string{"text", "yaml", "markdown"} {
+		template := editTemplates[name]
+		for _, tt := range editTemplateTestData {
+			data := tt.data
+			if name == "text" && !tt.text {
+				continue // a line of a field would be a section header
+			}
+			content, _ := template.render(data, "go")
+			got, problems := template.parse(content)
+			if len(problems) > 0 {
+				t.Errorf("%s: problems parsing the rendered %+v: %q\n%s", name, data, problems, content)
+				continue
+			}
+			if got != trimKeyData(data) {
+				t.Errorf("%s: parsed %+v, want %+v\n%s", name, got, trimKeyData(data), content)
+			}
+		}
+	}
+}
+
+func TestEditTemplatesFieldLines(t *testing.T) {
+	data := KeyData{InfoShort: "short", InfoLong: "long\ntext", Example: "\n  example"}
+	for name, template := range editTemplates {
+		content, fieldLines := template.render(data, "")
+		lines := strings.Split(content, "\n")
+		for _, field := range editSections {
+			first := strings.SplitN(strings.TrimLeft(*keyDataField(&data, field), "\n"), "\n", 2)[0]
+			line := fieldLines[field]
+			if line < 1 || !strings.Contains(strings.Join(lines[line-1:], "\n"), first) {
+				t.Errorf("%s: %s is not at line %d\n%s", name, field, line, content)
+			}
+		}
+	}
+}
+
+func TestMarkdownTemplateProblems(t *testing.T) {
+	tests := []struct {
+		content string
+		problem string
+	}{
+		{"long\n## Example\n", "front matter with infoShort is missing"},
+		{"---\ninfoShort: x\n\nlong\n", "not terminated"},
+		{"---\ninfoShort: x\n---\nlong\n", "heading '## Example' is missing"},
+		{"---\ninfoShort: x\n---\nlong\n```\n## Example\n```\n", "heading '## Example' is missing"},
+		{"---\nother: x\n---\n## Example\n", "'other' is not a front matter field"},
+	}
+	for _, tt := range tests {
+		_, problems := markdownTemplate{}.parse(tt.content)
+		if !strings.Contains(strings.Join(problems, "\n"), tt.problem) {
+			t.Errorf("parse(%q) problems %q, want %q", tt.content, problems, tt.problem)
+		}
+	}
+}
+
+func TestYAMLBlockIndicator(t *testing.T) {
+	tests := map[string]string{
+		"":             "|-",
+		"text":         "|-",
+		"text\n  more": "|-",
+		"  text":       "|2-",
+		"\n  text":     "|2-",
+		"  \ntext":     "|2-",
+		"\n\ntext":     "|-",
+	}
+	for value, want := range tests {
+		if got := yamlBlockIndicator(value); got != want {
+			t.Errorf("yamlBlockIndicator(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
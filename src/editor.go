@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// builtinEditor is the editor setting selecting the editor of recall -i
+const builtinEditor = "builtin"
+
+// fallbackEditors are tried when no editor is configured or found
+var fallbackEditors = []string{"nano", "vi"}
+
+// editorCommand is an editor command line such as "code --wait", which may
+// contain {file} and {line} placeholders
+type editorCommand struct {
+	Source string   // where the command was configured, for messages
+	Args   []string // the command line split into words
+}
+
+func (e editorCommand) String() string {
+	if len(e.Args) == 0 {
+		return "built-in editor"
+	}
+	return strings.Join(e.Args, " ")
+}
+
+// resolveEditor picks the editor from $VISUAL, $EDITOR, the editor setting or
+// the fallback editors, in this order, skipping those that are not installed.
+// Without any, it returns the built-in editor (an empty command).
+func resolveEditor(settings *Settings) editorCommand {
+	candidates := []editorCommand{}
+	for _, source := range []struct{ name, value string }{
+		{"$VISUAL", os.Getenv("VISUAL")},
+		{"$EDITOR", os.Getenv("EDITOR")},
+		{"settings.yaml", settings.Editor},
+	} {
+		if strings.TrimSpace(source.value) == "" {
+			continue
+		}
+		if strings.TrimSpace(source.value) == builtinEditor {
+			return editorCommand{Source: source.name}
+		}
+		args, err := splitShellWords(source.value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring editor '%s' from %s: %v\n", source.value, source.name, err)
+			continue
+		}
+		candidates = append(candidates, editorCommand{Source: source.name, Args: args})
+	}
+	for _, name := range fallbackEditors {
+		candidates = append(candidates, editorCommand{Source: "fallback", Args: []string{name}})
+	}
+
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate.Args[0]); err == nil {
+			return candidate
+		}
+		if candidate.Source != "fallback" {
+			fmt.Fprintf(os.Stderr, "[WARN] Editor '%s' from %s not found\n", candidate.Args[0], candidate.Source)
+		}
+	}
+	return editorCommand{Source: "fallback"}
+}
+
+// run opens file in the editor with the cursor at line, if the editor
+// supports it
+func (e editorCommand) run(file string, line int) error {
+	if len(e.Args) == 0 {
+		return editFileBuiltin(file, line)
+	}
+
+	args := make([]string, 0, len(e.Args)+1)
+	hasFile, hasLine := false, false
+	for _, arg := range e.Args {
+		if strings.Contains(arg, "{file}") {
+			hasFile = true
+		}
+		if strings.Contains(arg, "{line}") {
+			hasLine = true
+		}
+		arg = strings.ReplaceAll(arg, "{file}", file)
+		arg = strings.ReplaceAll(arg, "{line}", strconv.Itoa(line))
+		args = append(args, arg)
+	}
+	if !hasFile {
+		if hasLine || line <= 1 {
+			args = append(args, file)
+		} else {
+			args = append(args, lineArguments(args[0], file, line)...)
+		}
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// lineArguments returns the arguments opening file at line, for the editors
+// known to support it
+func lineArguments(editor, file string, line int) []string {
+	switch filepath.Base(editor) {
+	case "vi", "vim", "nvim", "nano", "emacs", "emacsclient", "micro", "kak", "joe", "mg", "gedit":
+		return []string{fmt.Sprintf("+%d", line), file}
+	case "code", "codium", "code-insiders":
+		return []string{"--goto", fmt.Sprintf("%s:%d", file, line)}
+	case "subl", "hx":
+		return []string{fmt.Sprintf("%s:%d", file, line)}
+	}
+	return []string{file}
+}
+
+// editFileBuiltin edits file with the full screen editor of recall -i
+func editFileBuiltin(file string, line int) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	t, err := openTerminal()
+	if err != nil {
+		return fmt.Errorf("no editor found, set $EDITOR or editor in settings.yaml (%v)", err)
+	}
+	edited, saved := editText(t, file, string(content), line)
+	t.close()
+	if !saved {
+		return nil
+	}
+	return ioutil.WriteFile(file, []byte(edited), 0600)
+}
+
+// splitShellWords splits a command line into words like a POSIX shell does,
+// honouring single quotes, double quotes and backslash escapes
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return words, nil
+}
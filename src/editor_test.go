@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+		err  string
+	}{
+		{"vim", []string{"vim"}, ""},
+		{"  code  --wait\t-n ", []string{"code", "--wait", "-n"}, ""},
+		{`'/Applications/Sublime Text.app/subl' -w`, []string{"/Applications/Sublime Text.app/subl", "-w"}, ""},
+		{`emacs --eval "(goto-line {line})" {file}`, []string{"emacs", "--eval", "(goto-line {line})", "{file}"}, ""},
+		{`my\ editor "say \"hi\"" 'it''s'`, []string{"my editor", `say "hi"`, "its"}, ""},
+		{`vim ""`, []string{"vim", ""}, ""},
+		{`a"b"'c'd`, []string{"abcd"}, ""},
+		{`'\'`, []string{`\`}, ""},
+		{"", nil, "empty command"},
+		{"   ", nil, "empty command"},
+		{`vim "unterminated`, nil, "unterminated \" quote"},
+		{`vim 'unterminated`, nil, "unterminated ' quote"},
+		{`vim \`, nil, "trailing backslash"},
+	}
+	for _, tt := range tests {
+		words, err := splitShellWords(tt.line)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("splitShellWords(%q) error %v, want %q", tt.line, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(words, tt.want) {
+			t.Errorf("splitShellWords(%q) = %q, %v, want %q", tt.line, words, err, tt.want)
+		}
+	}
+}
+
+func TestLineArguments(t *testing.T) {
+	tests := []struct {
+		editor string
+		want   []string
+	}{
+		{"/usr/bin/vim", []string{"+7", "f.txt"}},
+		{"nano", []string{"+7", "f.txt"}},
+		{"code", []string{"--goto", "f.txt:7"}},
+		{"hx", []string{"f.txt:7"}},
+		{"unknown-editor", []string{"f.txt"}},
+	}
+	for _, tt := range tests {
+		if got := lineArguments(tt.editor, "f.txt", 7); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lineArguments(%s) = %q, want %q", tt.editor, got, tt.want)
+		}
+	}
+}
+
+func TestResolveEditor(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", builtinEditor)
+	settings := defaultSettings()
+	settings.Editor = "vim"
+	if editor := resolveEditor(settings); len(editor.Args) != 0 || editor.Source != "$EDITOR" {
+		t.Errorf("resolveEditor = %+v, want the built-in editor from $EDITOR", editor)
+	}
+
+	// Editors that are not installed are skipped
+	t.Setenv("EDITOR", "missing-editor --wait")
+	if editor := resolveEditor(settings); len(editor.Args) != 0 {
+		t.Errorf("resolveEditor = %+v, want the built-in editor", editor)
+	}
+}
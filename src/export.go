@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// exportFormats maps the names accepted by --format to their renderer
+var exportFormats = map[string]func(project string, projectData ProjectData) (string, error){
+	"md":   exportMarkdown,
+	"html": exportHTML,
+	"man":  exportManPage,
+	"json": exportJSON,
+}
+
+// exportCommand handles "recall export <project> --format md|html|man|json [--output <file>]"
+func exportCommand(settings *Settings, args []string) {
+	args, format, err := extractOption(args, "--format")
+	output := ""
+	if err == nil {
+		args, output, err = extractOption(args, "--output")
+	}
+	if err != nil || len(args) != 1 {
+		fmt.Println("[ERROR] Usage: recall export <project> --format md|html|man|json [--output <file>]")
+		os.Exit(1)
+	}
+	if format == "" {
+		format = "md"
+	}
+	render, ok := exportFormats[format]
+	if !ok {
+		fmt.Printf("[ERROR] Unknown export format '%s', expected md, html, man or json\n", format)
+		os.Exit(1)
+	}
+
+	project := args[0]
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	projectData := loadProjectData(projectFile)
+	if len(projectData) == 0 {
+		fmt.Printf("[ERROR] Project '%s' not found. Use --edit to create it.\n", project)
+		return
+	}
+
+	content, err := render(project, projectData)
+	if err != nil {
+		fmt.Printf("[ERROR] Could not export %s: %v\n", project, err)
+		return
+	}
+
+	if output == "" {
+		fmt.Print(content)
+		return
+	}
+	if err := ioutil.WriteFile(output, []byte(content), 0644); err != nil {
+		fmt.Printf("[ERROR] Could not write %s: %v\n", output, err)
+		return
+	}
+	fmt.Printf("[INFO] Exported %s to %s\n", project, output)
+}
+
+// exportMarkdown renders the project as a Markdown document with a heading per key
+func exportMarkdown(project string, projectData ProjectData) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("# " + project + "\n")
+
+	walkKeys(projectData, func(keyPath []string, data KeyData) {
+		if len(keyPath) > 0 {
+			level := len(keyPath) + 1
+			if level > 6 {
+				level = 6
+			}
+			sb.WriteString("\n" + strings.Repeat("#", level) + " " + keyPath[len(keyPath)-1] + "\n")
+		}
+		if data.InfoShort != "" {
+			sb.WriteString("\n" + data.InfoShort + "\n")
+		}
+		if data.InfoLong != "" {
+			sb.WriteString("\n" + data.InfoLong + "\n")
+		}
+		if data.Example != "" {
+			fence := markdownFence(data.Example)
+			sb.WriteString("\n" + fence + "\n" + strings.TrimSuffix(data.Example, "\n") + "\n" + fence + "\n")
+		}
+	})
+	return sb.String(), nil
+}
+
+// exportHTML renders the project as a standalone HTML page with a sidebar
+// linking to every key
+func exportHTML(project string, projectData ProjectData) (string, error) {
+	var nav, body strings.Builder
+	depth := 0
+
+	walkKeys(projectData, func(keyPath []string, data KeyData) {
+		id := "info"
+		title := project
+		if len(keyPath) > 0 {
+			id = htmlKeyID(keyPath)
+			title = keyPath[len(keyPath)-1]
+
+			// Open or close nested lists to follow the key hierarchy. A
+			// nested list goes inside the <li> of its parent key.
+			if depth < len(keyPath) {
+				for depth < len(keyPath) {
+					nav.WriteString("<ul>")
+					depth++
+				}
+			} else {
+				nav.WriteString("</li>")
+				for depth > len(keyPath) {
+					nav.WriteString("</ul></li>")
+					depth--
+				}
+			}
+			nav.WriteString(fmt.Sprintf(`<li><a href="#%s">%s</a>`, html.EscapeString(id), html.EscapeString(title)))
+		}
+
+		level := len(keyPath) + 1
+		if level > 6 {
+			level = 6
+		}
+		body.WriteString(fmt.Sprintf("<section id=\"%s\">\n<h%d>%s</h%d>\n", html.EscapeString(id), level, html.EscapeString(title), level))
+		if len(keyPath) > 1 {
+			body.WriteString(fmt.Sprintf("<p class=\"path\">%s</p>\n", html.EscapeString(strings.Join(keyPath, " → "))))
+		}
+		if data.InfoShort != "" {
+			body.WriteString("<p class=\"short\">" + html.EscapeString(data.InfoShort) + "</p>\n")
+		}
+		if data.InfoLong != "" {
+			for _, paragraph := range strings.Split(data.InfoLong, "\n\n") {
+				body.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n") + "</p>\n")
+			}
+		}
+		if data.Example != "" {
+			body.WriteString("<pre><code>" + html.EscapeString(strings.TrimSuffix(data.Example, "\n")) + "</code></pre>\n")
+		}
+		body.WriteString("</section>\n")
+	})
+	for ; depth > 0; depth-- {
+		nav.WriteString("</li></ul>")
+	}
+
+	title := html.EscapeString(project)
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>` + title + `</title>
+<style>
+body { margin: 0; font-family: sans-serif; line-height: 1.5; }
+nav { position: fixed; top: 0; bottom: 0; left: 0; width: 16rem; overflow-y: auto; padding: 1rem; background: #f4f4f4; box-sizing: border-box; }
+nav ul { list-style: none; padding-left: 1rem; margin: 0; }
+nav > ul { padding-left: 0; }
+nav a { color: #333; text-decoration: none; }
+nav a:hover { text-decoration: underline; }
+main { margin-left: 16rem; padding: 1rem 2rem; max-width: 50rem; }
+section { border-bottom: 1px solid #ddd; padding-bottom: 1rem; }
+.short { font-weight: bold; }
+.path { color: #777; font-size: 0.9em; margin: 0; }
+pre { background: #f4f4f4; padding: 0.75rem; overflow-x: auto; }
+</style>
+</head>
+<body>
+<nav>
+<a href="#info"><strong>` + title + `</strong></a>
+` + nav.String() + `
+</nav>
+<main>
+` + body.String() + `</main>
+</body>
+</html>
+`, nil
+}
+
+// htmlKeyID returns the anchor of a key. Segments are escaped, so '/' only
+// ever separates them and every key path gets its own id.
+func htmlKeyID(keyPath []string) string {
+	segments := make([]string, len(keyPath))
+	for i, key := range keyPath {
+		segments[i] = url.PathEscape(key)
+	}
+	return "key-" + strings.Join(segments, "/")
+}
+
+// exportManPage renders the project as a roff man page in section 7
+func exportManPage(project string, projectData ProjectData) (string, error) {
+	info := getKeyData(projectData, "info")
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(".TH \"%s\" 7 \"%s\" \"recall %s\"\n", strings.ToUpper(roffEscape(project)), time.Now().Format("2006-01-02"), version))
+	sb.WriteString(".SH NAME\n")
+	sb.WriteString(roffEscape(project))
+	if info.InfoShort != "" {
+		sb.WriteString(" \\- " + roffEscape(strings.Join(strings.Fields(info.InfoShort), " ")))
+	}
+	sb.WriteString("\n")
+
+	walkKeys(projectData, func(keyPath []string, data KeyData) {
+		if len(keyPath) == 0 {
+			if data.InfoLong != "" || data.Example != "" {
+				sb.WriteString(".SH DESCRIPTION\n")
+				writeRoffText(&sb, data.InfoLong)
+				writeRoffExample(&sb, data.Example)
+			}
+			return
+		}
+		if len(keyPath) == 1 {
+			sb.WriteString(".SH \"" + roffArgument(strings.ToUpper(keyPath[0])) + "\"\n")
+		} else {
+			segments := make([]string, len(keyPath))
+			for i, key := range keyPath {
+				segments[i] = roffArgument(key)
+			}
+			sb.WriteString(".SS \"" + strings.Join(segments, " \\(-> ") + "\"\n")
+		}
+		if data.InfoShort != "" {
+			// .B makes its argument bold, the text must be on the same line
+			sb.WriteString(".PP\n.B \"" + roffArgument(data.InfoShort) + "\"\n")
+		}
+		writeRoffText(&sb, data.InfoLong)
+		writeRoffExample(&sb, data.Example)
+	})
+	return sb.String(), nil
+}
+
+// roffEscape escapes backslashes and control characters at the start of a line
+func roffEscape(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\\", "\\e"), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roffArgument escapes text for a quoted argument of a request like .B,
+// which must fit on one line
+func roffArgument(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\\", "\\e"), "\"", "\\(dq")
+}
+
+func writeRoffText(sb *strings.Builder, text string) {
+	if text == "" {
+		return
+	}
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		sb.WriteString(".PP\n" + roffEscape(paragraph) + "\n")
+	}
+}
+
+func writeRoffExample(sb *strings.Builder, example string) {
+	if example == "" {
+		return
+	}
+	sb.WriteString(".PP\n.RS 4\n.nf\n" + roffEscape(strings.TrimSuffix(example, "\n")) + "\n.fi\n.RE\n")
+}
+
+// exportJSON renders the raw project data, including nested keys and any
+// additional fields
+func exportJSON(project string, projectData ProjectData) (string, error) {
+	data, err := json.MarshalIndent(normalizeValue(projectData), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExportManPageBoldInfoShort(t *testing.T) {
+	projectData := ProjectData{
+		"database": map[string]interface{}{
+			"infoShort": `Connects to "main" db\n`,
+			"infoLong":  "Uses a pool.",
+			"keys": map[string]interface{}{
+				"pool": map[string]interface{}{"infoShort": ".hidden start"},
+			},
+		},
+	}
+	page, err := exportManPage("app", projectData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		".SH \"DATABASE\"\n.PP\n.B \"Connects to \\(dqmain\\(dq db\\en\"\n.PP\nUses a pool.\n",
+		".SS \"database \\(-> pool\"\n.PP\n.B \".hidden start\"\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("man page is missing %q:\n%s", want, page)
+		}
+	}
+
+	if _, err := exec.LookPath("groff"); err == nil {
+		cmd := exec.Command("groff", "-man", "-Tutf8", "-ww", "-z")
+		cmd.Stdin = strings.NewReader(page)
+		if out, err := cmd.CombinedOutput(); err != nil || len(out) > 0 {
+			t.Errorf("groff: %v %s", err, out)
+		}
+	}
+}
+
+func TestHTMLKeyIDsAreUnique(t *testing.T) {
+	paths := [][]string{{"a-b"}, {"a", "b"}, {"a.b"}, {"a/b"}, {"a b"}, {"a%20b"}}
+	seen := make(map[string][]string)
+	for _, keyPath := range paths {
+		id := htmlKeyID(keyPath)
+		if other, ok := seen[id]; ok {
+			t.Errorf("%v and %v both get id %q", other, keyPath, id)
+		}
+		if strings.ContainsAny(id, " \t\n") {
+			t.Errorf("id %q of %v contains whitespace", id, keyPath)
+		}
+		seen[id] = keyPath
+	}
+}
+
+func TestExportHTMLNestsListsInItems(t *testing.T) {
+	projectData := parseTestYAML(t, "a:\n  keys:\n    b:\n      keys:\n        c:\n          infoShort: c\nd:\n  infoShort: d\n")
+	page, err := exportHTML("app", projectData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := func(id, title string) string { return `<li><a href="#` + id + `">` + title + `</a>` }
+	want := "<ul>" + item("key-a", "a") +
+		"<ul>" + item("key-a/b", "b") +
+		"<ul>" + item("key-a/b/c", "c") + "</li></ul></li></ul></li>" +
+		item("key-d", "d") + "</li></ul>"
+	if !strings.Contains(page, want) {
+		t.Errorf("navigation is not %s:\n%s", want, page)
+	}
+}
@@ -5,51 +5,195 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"gopkg.in/yaml.v2"
 )
 
-func findProjectFile(project string) string {
-	// Check if local .recall directory exists
-	if _, err := os.Stat("./.recall"); err == nil {
-		// Local .recall directory exists, prefer local storage
-		localFile := fmt.Sprintf("./.recall/%s.yaml", project)
-		return localFile
+// yamlBackend stores each project as <store>/<project>.yaml
+type yamlBackend struct{}
+
+func (yamlBackend) projectPath(storeDir, project string) (string, error) {
+	base, err := projectBasePath(storeDir, project)
+	if err != nil {
+		return "", err
+	}
+	return base + ".yaml", nil
+}
+
+func (yamlBackend) load(location projectLocation) (ProjectData, error) {
+	filename := location.Path
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		// File doesn't exist, return empty project data
+		return make(ProjectData), nil
+	}
+	
+	projectData, err := readYAMLFile(filename)
+	if err != nil {
+		return nil, err
 	}
 	
-	// No local .recall directory, check if project file exists locally first
-	localFile := fmt.Sprintf("./.recall/%s.yaml", project)
-	if _, err := os.Stat(localFile); err == nil {
-		return localFile
+	// Merge the top-level keys of included files into the project
+	includes, err := includeFiles(filename, projectData)
+	if err != nil {
+		return nil, err
+	}
+	for _, include := range includes {
+		includeData, err := readYAMLFile(include)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range includeData {
+			if _, exists := projectData[key]; exists {
+				fmt.Printf("[WARN] Key '%s' from %s is already defined, ignoring it\n", key, include)
+				continue
+			}
+			projectData[key] = value
+		}
 	}
 	
-	// Fall back to global storage
-	homeDir, _ := os.UserHomeDir()
-	globalFile := fmt.Sprintf("%s/.recall/%s.yaml", homeDir, project)
-	return globalFile
+	return projectData, nil
 }
 
-func loadProjectData(filename string) ProjectData {
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		// File doesn't exist, return empty project data
-		return make(ProjectData)
+func (yamlBackend) save(location projectLocation, projectData ProjectData) error {
+	filename := location.Path
+	includes, err := includeFiles(filename, projectData)
+	if err != nil {
+		return err
+	}
+	
+	// Write every top-level key back to the file its value was loaded from:
+	// like in load, the project file wins over its includes and earlier
+	// includes over later ones. New keys go to the project file itself.
+	owner := make(map[string]string)
+	if _, err := os.Stat(filename); err == nil {
+		existing, err := readYAMLFile(filename)
+		if err != nil {
+			return err
+		}
+		for key := range existing {
+			owner[key] = filename
+		}
+	}
+	for _, include := range includes {
+		includeData := make(ProjectData)
+		if _, err := os.Stat(include); err == nil {
+			if includeData, err = readYAMLFile(include); err != nil {
+				return err
+			}
+		}
+		for key := range includeData {
+			if _, taken := owner[key]; !taken {
+				owner[key] = include
+			}
+			if owner[key] != include {
+				// Shadowed by another file, so it was ignored by load: keep it as is
+				continue
+			}
+			if value, exists := projectData[key]; exists {
+				includeData[key] = value
+			} else {
+				delete(includeData, key)
+			}
+		}
+		if err := writeYAMLFile(include, includeData); err != nil {
+			return err
+		}
 	}
 	
+	mainData := make(ProjectData)
+	for key, value := range projectData {
+		if owner[key] == "" || owner[key] == filename {
+			mainData[key] = value
+		}
+	}
+	return writeYAMLFile(filename, mainData)
+}
+
+func (yamlBackend) listProjects(storeDir, namespace string) ([]string, error) {
+	root := storeDir
+	if namespace != "" {
+		root = filepath.Join(storeDir, filepath.FromSlash(namespace))
+	}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var projects []string
+	included := make(map[string]bool)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Hidden files and directories hold recall's own data, never projects,
+		// and <project>.d directories hold files included by a project
+		if path != root && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path != root && info.IsDir() && filepath.Ext(path) == ".d" {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(storeDir, path)
+		if err != nil {
+			return err
+		}
+		project := filepath.ToSlash(strings.TrimSuffix(rel, ".yaml"))
+		if validateProjectName(project) == nil {
+			projects = append(projects, project)
+		}
+
+		// Files included by a project are part of it, wherever they are
+		if projectData, err := readYAMLFile(path); err == nil {
+			includes, _ := includeFiles(path, projectData)
+			for _, include := range includes {
+				included[include] = true
+			}
+		}
+		return nil
+	})
+
+	var listed []string
+	for _, project := range projects {
+		if !included[filepath.Join(storeDir, filepath.FromSlash(project))+".yaml"] {
+			listed = append(listed, project)
+		}
+	}
+	sort.Strings(listed)
+	return listed, err
+}
+
+func (yamlBackend) remove(location projectLocation) error {
+	if err := os.Remove(location.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// readYAMLFile parses a single YAML file into ProjectData
+func readYAMLFile(filename string) (ProjectData, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		fmt.Printf("[ERROR] Could not read file: %v\n", err)
-		return make(ProjectData)
+		return nil, fmt.Errorf("could not read file: %v", err)
 	}
 	
 	var projectData ProjectData
 	if err := yaml.Unmarshal(data, &projectData); err != nil {
-		fmt.Printf("[ERROR] Could not parse YAML: %v\n", err)
-		return make(ProjectData)
+		return nil, fmt.Errorf("could not parse YAML in %s: %v", filename, err)
 	}
-	
-	return projectData
+	if projectData == nil {
+		projectData = make(ProjectData)
+	}
+	return projectData, nil
 }
 
-func saveProjectData(filename string, projectData ProjectData) error {
+func writeYAMLFile(filename string, projectData ProjectData) error {
 	// Ensure directory exists
 	dir := filepath.Dir(filename)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -64,26 +208,73 @@ func saveProjectData(filename string, projectData ProjectData) error {
 	return ioutil.WriteFile(filename, data, 0644)
 }
 
-func createTempEditFile(data KeyData) (string, error) {
-	file, err := ioutil.TempFile(os.TempDir(), "recall_edit_*.txt")
+// includeFiles resolves the "include:" entries of a project file. Entries are
+// file names or glob patterns relative to the project file, e.g.
+//
+//	include:
+//	  - recall.d/*.yaml
+//
+// and must stay inside the directory of the project file.
+func includeFiles(filename string, projectData ProjectData) ([]string, error) {
+	var patterns []string
+	switch v := projectData["include"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		patterns = []string{v}
+	case []interface{}:
+		for _, entry := range v {
+			if pattern, ok := entry.(string); ok {
+				patterns = append(patterns, pattern)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("include in %s must be a file name or a list of file names", filename)
+	}
+	
+	baseDir := filepath.Dir(filename)
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		clean := filepath.Clean(filepath.FromSlash(pattern))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("include '%s' in %s must stay inside %s", pattern, filename, baseDir)
+		}
+		
+		matches, err := filepath.Glob(filepath.Join(baseDir, clean))
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern '%s': %v", pattern, err)
+		}
+		// A plain file name that doesn't exist yet is still a valid target
+		if len(matches) == 0 && !strings.ContainsAny(clean, "*?[") {
+			matches = []string{filepath.Join(baseDir, clean)}
+		}
+		for _, match := range matches {
+			if match == filepath.Clean(filename) || seen[match] {
+				continue
+			}
+			seen[match] = true
+			files = append(files, match)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// createTempEditFile writes data in the given template to a temporary file
+// and returns its name and the line of each field
+func createTempEditFile(template editTemplate, data KeyData, language string) (string, map[string]int, error) {
+	file, err := ioutil.TempFile(os.TempDir(), "recall_edit_*"+template.extension())
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer file.Close()
 	
-	content := fmt.Sprintf(`infoShort:
-%s
-
-infoLong:
-%s
-
-example:
-%s
-`, data.InfoShort, data.InfoLong, data.Example)
+	content, fieldLines := template.render(data, language)
 	
 	if _, err := file.WriteString(content); err != nil {
-		return "", err
+		return "", nil, err
 	}
 	
-	return file.Name(), nil
+	return file.Name(), fieldLines, nil
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func yamlTestLocation(t *testing.T, dir, project string) projectLocation {
+	t.Helper()
+	path, err := yamlBackend{}.projectPath(dir, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return projectLocation{Store: dir, Project: project, Path: path, Backend: yamlBackend{}}
+}
+
+func TestIncludeFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "app.d", "b.yaml"), "b: {}\n")
+	writeTestFile(t, filepath.Join(dir, "app.d", "a.yaml"), "a: {}\n")
+	filename := filepath.Join(dir, "app.yaml")
+
+	tests := []struct {
+		include interface{}
+		want    []string
+	}{
+		{nil, nil},
+		{"extra.yaml", []string{filepath.Join(dir, "extra.yaml")}},
+		{[]interface{}{"app.d/*.yaml"}, []string{filepath.Join(dir, "app.d", "a.yaml"), filepath.Join(dir, "app.d", "b.yaml")}},
+		{[]interface{}{"app.d/a.yaml", "app.d/*.yaml", "app.yaml"}, []string{filepath.Join(dir, "app.d", "a.yaml"), filepath.Join(dir, "app.d", "b.yaml")}},
+	}
+	for _, tt := range tests {
+		got, err := includeFiles(filename, ProjectData{"include": tt.include})
+		if err != nil {
+			t.Errorf("includeFiles(%v): %v", tt.include, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("includeFiles(%v) = %v, want %v", tt.include, got, tt.want)
+		}
+	}
+
+	for _, include := range []interface{}{"../outside.yaml", "/etc/passwd", 42} {
+		if _, err := includeFiles(filename, ProjectData{"include": include}); err == nil {
+			t.Errorf("includeFiles(%v) succeeded, want an error", include)
+		}
+	}
+}
+
+func TestYAMLBackendSaveKeepsFileLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "app.yaml"), "include:\n- app.d/*.yaml\ndb:\n  infoShort: main\n")
+	writeTestFile(t, filepath.Join(dir, "app.d", "a.yaml"), "db:\n  infoShort: shadowed\napi:\n  infoShort: api\n")
+	writeTestFile(t, filepath.Join(dir, "app.d", "b.yaml"), "api:\n  infoShort: shadowed too\ncache:\n  infoShort: cache\n")
+	location := yamlTestLocation(t, dir, "app")
+
+	projectData, err := yamlBackend{}.load(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := getKeyData(projectData, "db").InfoShort; got != "main" {
+		t.Fatalf("db loaded from %q, want the project file's value", got)
+	}
+
+	// Change a key of each file and add a new one
+	setKeyData(projectData, "db", KeyData{InfoShort: "main changed"})
+	setKeyData(projectData, "api", KeyData{InfoShort: "api changed"})
+	delete(projectData, "cache")
+	setKeyData(projectData, "new", KeyData{InfoShort: "new"})
+	if err := (yamlBackend{}).save(location, projectData); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]map[string]string{
+		"app.yaml":     {"db": "main changed", "new": "new"},
+		"app.d/a.yaml": {"db": "shadowed", "api": "api changed"},
+		"app.d/b.yaml": {"api": "shadowed too"},
+	}
+	for name, want := range files {
+		data, err := readYAMLFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]string)
+		for key := range data {
+			if key != "include" {
+				got[key] = getKeyData(data, key).InfoShort
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestYAMLBackendListProjectsSkipsIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "app.yaml"), "include:\n- extra.yaml\n- app.d/*.yaml\n")
+	writeTestFile(t, filepath.Join(dir, "extra.yaml"), "db: {}\n")
+	writeTestFile(t, filepath.Join(dir, "app.d", "a.yaml"), "api: {}\n")
+	writeTestFile(t, filepath.Join(dir, "team", "backend.yaml"), "info: {}\n")
+	if err := os.MkdirAll(filepath.Join(dir, ".history"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, ".history", "app.yaml"), "[]\n")
+
+	got, err := yamlBackend{}.listProjects(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app", "team/backend"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listProjects = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// runGit runs the git binary in dir and returns its trimmed output
+func runGit(dir string, args ...string) (string, error) {
+	return runGitEnv(dir, nil, args...)
+}
+
+// runGitEnv runs git like runGit with additional environment variables
+func runGitEnv(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// insideWorkTree reports whether dir is part of a git work tree
+func insideWorkTree(dir string) bool {
+	out, err := runGit(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// commitMessage names the project and the changed keys. Every key gets its own
+// "Key:" trailer line so `recall --log` can find the commits touching a key.
+func commitMessage(project string, changes []historyEntry) string {
+	var labels, trailers []string
+	for _, change := range changes {
+		if len(change.KeyPath) == 0 {
+			labels = append(labels, "general info")
+		} else {
+			labels = append(labels, strings.Join(change.KeyPath, " → "))
+		}
+		trailers = append(trailers, strings.TrimSpace("Key: "+project+" "+strings.Join(change.KeyPath, " ")))
+	}
+
+	subject := "recall: update " + project
+	if len(labels) <= 3 {
+		subject += " (" + strings.Join(labels, ", ") + ")"
+	} else {
+		subject += fmt.Sprintf(" (%d keys)", len(labels))
+	}
+	return subject + "\n\n" + strings.Join(trailers, "\n") + "\n"
+}
+
+// commitStoreChanges commits the files of the saved project and its history,
+// leaving other projects and any other changes of the repository alone
+func commitStoreChanges(location projectLocation, changes []historyEntry) error {
+	if len(changes) == 0 || !insideWorkTree(location.Store) {
+		return nil
+	}
+
+	// Files that neither exist nor are tracked can't be named to git
+	var paths []string
+	for _, path := range projectFiles(location) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(absPath); err == nil {
+			paths = append(paths, absPath)
+		} else if tracked, _ := runGit(location.Store, "ls-files", "--", absPath); tracked != "" {
+			paths = append(paths, absPath)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	if _, err := runGit(location.Store, append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return err
+	}
+	if out, _ := runGit(location.Store, append([]string{"status", "--porcelain", "--"}, paths...)...); out == "" {
+		return nil
+	}
+	_, err := runGit(location.Store, append([]string{"commit", "--quiet", "-m", commitMessage(location.Project, changes), "--"}, paths...)...)
+	return err
+}
+
+// projectFiles returns the file or directory holding a project, the files it
+// includes and its history file
+func projectFiles(location projectLocation) []string {
+	files := []string{location.Path, historyFile(location)}
+	if _, ok := location.Backend.(yamlBackend); ok {
+		if projectData, err := readYAMLFile(location.Path); err == nil {
+			includes, _ := includeFiles(location.Path, projectData)
+			files = append(files, includes...)
+		}
+	}
+	return files
+}
+
+// logCommand shows the git history of a project's store filtered to the
+// commits recall made for a project or key (including its sub-keys)
+func logCommand(settings *Settings, args []string) {
+	if len(args) == 0 {
+		fmt.Println("[ERROR] Usage: recall --log <project> [key...]")
+		os.Exit(1)
+	}
+	project, keyPath := args[0], args[1:]
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	if !insideWorkTree(projectFile.Store) {
+		fmt.Printf("[ERROR] %s is not inside a git work tree\n", projectFile.Store)
+		return
+	}
+
+	var quoted []string
+	for _, segment := range append([]string{project}, keyPath...) {
+		quoted = append(quoted, regexp.QuoteMeta(segment))
+	}
+	pattern := "^Key: " + strings.Join(quoted, " ") + "( |$)"
+
+	out, err := runGit(projectFile.Store, "log", "--extended-regexp", "--grep", pattern,
+		"--date=short", "--format=%C(yellow)%h%Creset %ad %an  %s", "--color=always", "--", ".")
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	if out == "" {
+		fmt.Printf("[INFO] No commits found for %s\n", strings.Join(args, " "))
+		return
+	}
+	fmt.Println(out)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// useTestGit skips the test without git and gives it a committer identity,
+// independent of the user's configuration
+func useTestGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "Test")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+}
+
+// initTestRepo creates a git repository in dir
+func initTestRepo(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	useTestGit(t)
+	if _, err := runGit(dir, append([]string{"init", "--quiet"}, args...)...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommitStoreChangesOnlyCommitsTheProject(t *testing.T) {
+	work := useTempStores(t)
+	initTestRepo(t, work)
+	writeTestFile(t, filepath.Join(work, "README"), "pending edit\n")
+	writeTestFile(t, filepath.Join(localStoreDir, "other.yaml"), "db:\n  infoShort: pending\n")
+	writeTestFile(t, filepath.Join(localStoreDir, "app.d", "db.yaml"), "db:\n  infoShort: old\n")
+	writeTestFile(t, filepath.Join(localStoreDir, "app.yaml"), "include: app.d/*.yaml\n")
+
+	settings := defaultSettings()
+	settings.GitAutoCommit = true
+	location, err := findProjectFile(settings, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectData := loadProjectData(location)
+	setKeyData(projectData, "db", KeyData{InfoShort: "new"})
+	if err := saveProjectData(location, projectData); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runGit(work, "show", "--name-only", "--format=%s", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out, "\n")
+	if lines[0] != "recall: update app (db)" {
+		t.Errorf("subject = %q", lines[0])
+	}
+	files := lines[2:]
+	sort.Strings(files)
+	want := []string{".recall/.history/app.yaml", ".recall/app.d/db.yaml", ".recall/app.yaml"}
+	if strings.Join(files, " ") != strings.Join(want, " ") {
+		t.Errorf("committed %v, want %v", files, want)
+	}
+}
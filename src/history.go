@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"gopkg.in/yaml.v2"
+)
+
+// historyDir holds the change log of each project, inside its store
+const historyDir = ".history"
+
+// historyEntry records one change of a key. The log of a project is a stream
+// of YAML documents so new entries can be appended without rewriting it.
+type historyEntry struct {
+	Time    string         `yaml:"time"`
+	User    string         `yaml:"user"`
+	KeyPath []string       `yaml:"key,flow"`
+	Old     KeyData        `yaml:"old"`
+	New     KeyData        `yaml:"new"`
+	Journal []journalEntry `yaml:"journal,omitempty"` // journal entries added by the change
+}
+
+// historyFile returns <store>/.history/<project>.yaml
+func historyFile(location projectLocation) string {
+	return filepath.Join(location.Store, historyDir, filepath.FromSlash(location.Project)+".yaml")
+}
+
+// currentUser names the author of a change
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// projectChanges returns an entry for every key whose info fields differ
+// between the previously saved and the new project data, or whose journal grew
+func projectChanges(previous, projectData ProjectData) []historyEntry {
+	before := make(map[string]map[string]interface{})
+	walkNodes(previous, func(keyPath []string, node map[string]interface{}) {
+		before[keyPathString(keyPath)] = node
+	})
+
+	now := time.Now().Format(time.RFC3339)
+	author := currentUser()
+	var entries []historyEntry
+	walkNodes(projectData, func(keyPath []string, node map[string]interface{}) {
+		path := keyPathString(keyPath)
+		oldNode, existed := before[path]
+		delete(before, path)
+		old, data := keyDataFromNode(oldNode), keyDataFromNode(node)
+		added := addedJournalEntries(oldNode, node)
+		if existed && old == data && len(added) == 0 {
+			return
+		}
+		entries = append(entries, historyEntry{Time: now, User: author, KeyPath: keyPath, Old: old, New: data, Journal: added})
+	})
+	// Whatever is left was removed
+	walkKeys(previous, func(keyPath []string, data KeyData) {
+		if _, removed := before[keyPathString(keyPath)]; removed {
+			entries = append(entries, historyEntry{Time: now, User: author, KeyPath: keyPath, Old: data})
+		}
+	})
+	return entries
+}
+
+// recordHistory appends entries to the change log of a project
+func recordHistory(location projectLocation, entries []historyEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	filename := historyFile(location)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, entry := range entries {
+		data, err := yaml.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := file.WriteString("---\n" + string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadKeyHistory returns the entries of a single key, oldest first
+func loadKeyHistory(location projectLocation, keyPath []string) ([]historyEntry, error) {
+	file, err := os.Open(historyFile(location))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	path := keyPathString(keyPath)
+	var entries []historyEntry
+	decoder := yaml.NewDecoder(file)
+	for {
+		var entry historyEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %v", historyFile(location), err)
+		}
+		if keyPathString(entry.KeyPath) == path {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// historyCommand handles
+//
+//	recall --history <project> <key>...                 list revisions
+//	recall --history <project> <key>... --diff <n> [m]  show a revision, or compare two
+//	recall --history <project> <key>... --revert <n>    restore a revision
+func historyCommand(settings *Settings, args []string) {
+	args, revertArg, err := extractOption(args, "--revert")
+	diffArgs := []string{}
+	for i, arg := range args {
+		if arg == "--diff" {
+			diffArgs = args[i+1:]
+			args = args[:i]
+			if len(diffArgs) == 0 || len(diffArgs) > 2 {
+				err = fmt.Errorf("--diff requires one or two revisions")
+			}
+			break
+		}
+	}
+	if err != nil || len(args) == 0 {
+		fmt.Println("[ERROR] Usage: recall --history <project> [key...] [--diff <n> [m] | --revert <n>]")
+		os.Exit(1)
+	}
+
+	project, keyPath := args[0], args[1:]
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	entries, err := loadKeyHistory(projectFile, keyPath)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+
+	label := strings.Join(keyPath, " → ")
+	if len(keyPath) == 0 {
+		label = "(general info)"
+	}
+	if len(entries) == 0 {
+		fmt.Printf("[INFO] No history recorded for %s %s\n", project, label)
+		return
+	}
+
+	revision := func(arg string) (historyEntry, bool) {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > len(entries) {
+			fmt.Printf("[ERROR] Unknown revision '%s', %s has revisions 1 to %d\n", arg, label, len(entries))
+			return historyEntry{}, false
+		}
+		return entries[n-1], true
+	}
+
+	switch {
+	case revertArg != "":
+		entry, ok := revision(revertArg)
+		if !ok {
+			return
+		}
+		projectData := loadProjectData(projectFile)
+		setKeyData(projectData, keyPathString(keyPath), entry.New)
+		if err := saveProjectData(projectFile, projectData); err != nil {
+			fmt.Printf("[ERROR] Error saving %s: %v\n", projectFile.Path, err)
+			return
+		}
+		fmt.Printf("[INFO] Reverted %s to revision %s\n", label, revertArg)
+
+	case len(diffArgs) == 1:
+		entry, ok := revision(diffArgs[0])
+		if !ok {
+			return
+		}
+		fmt.Printf("Revision %s of %s (%s, %s):\n", diffArgs[0], label, entry.Time, entry.User)
+		printKeyDataDiff(entry.Old, entry.New)
+
+	case len(diffArgs) == 2:
+		from, ok := revision(diffArgs[0])
+		if !ok {
+			return
+		}
+		to, ok := revision(diffArgs[1])
+		if !ok {
+			return
+		}
+		fmt.Printf("Changes of %s from revision %s to %s:\n", label, diffArgs[0], diffArgs[1])
+		printKeyDataDiff(from.New, to.New)
+
+	default:
+		fmt.Printf("History of %s %s:\n", project, label)
+		for i, entry := range entries {
+			fmt.Printf("  \033[1;32m%3d\033[0m  %s  %-12s %s\n", i+1, entry.Time, entry.User, describeChange(entry))
+		}
+	}
+}
+
+// describeChange summarizes which fields an entry changed
+func describeChange(entry historyEntry) string {
+	if entry.Old == entry.New && len(entry.Journal) > 0 {
+		return "added journal entry"
+	}
+	if entry.Old == (KeyData{}) {
+		return "created"
+	}
+	if entry.New == (KeyData{}) {
+		return "cleared"
+	}
+	var fields []string
+	if entry.Old.InfoShort != entry.New.InfoShort {
+		fields = append(fields, "infoShort")
+	}
+	if entry.Old.InfoLong != entry.New.InfoLong {
+		fields = append(fields, "infoLong")
+	}
+	if entry.Old.Example != entry.New.Example {
+		fields = append(fields, "example")
+	}
+	return "changed " + strings.Join(fields, ", ")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProjectChanges(t *testing.T) {
+	previous := parseTestYAML(t, "db:\n  infoShort: old\n  keys:\n    pool:\n      infoShort: p\napi:\n  infoShort: a\nlog:\n  journal:\n  - time: \"1\"\n    text: one\n")
+	current := parseTestYAML(t, "db:\n  infoShort: new\n  keys:\n    pool:\n      infoShort: p\nlog:\n  journal:\n  - time: \"1\"\n    text: one\n  - time: \"2\"\n    text: two\ncache:\n  infoShort: c\n")
+
+	changes := make(map[string]historyEntry)
+	for _, entry := range projectChanges(previous, current) {
+		changes[strings.Join(entry.KeyPath, " ")] = entry
+	}
+	if len(changes) != 4 {
+		t.Errorf("changes of %v, want db, api, log and cache", changes)
+	}
+	if entry := changes["db"]; entry.Old.InfoShort != "old" || entry.New.InfoShort != "new" {
+		t.Errorf("db change = %+v", entry)
+	}
+	if entry := changes["api"]; entry.Old.InfoShort != "a" || entry.New != (KeyData{}) {
+		t.Errorf("removal of api = %+v", entry)
+	}
+	if entry := changes["cache"]; entry.Old != (KeyData{}) || entry.New.InfoShort != "c" {
+		t.Errorf("creation of cache = %+v", entry)
+	}
+	if entry := changes["log"]; len(entry.Journal) != 1 || entry.Journal[0].Text != "two" {
+		t.Errorf("journal change = %+v", entry)
+	}
+}
+
+func TestRecordAndLoadKeyHistory(t *testing.T) {
+	location := projectLocation{Store: t.TempDir(), Project: "team/app"}
+	for _, text := range []string{"one", "two"} {
+		entries := []historyEntry{
+			{Time: text, KeyPath: []string{"db"}, New: KeyData{InfoShort: text, InfoLong: "---\nnot a document"}},
+			{Time: text, KeyPath: []string{"db", "pool"}, New: KeyData{InfoShort: "pool " + text}},
+		}
+		if err := recordHistory(location, entries); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := loadKeyHistory(location, []string{"db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].New.InfoShort != "one" || entries[1].New.InfoShort != "two" {
+		t.Errorf("history of db = %+v", entries)
+	}
+	if entries, _ := loadKeyHistory(location, []string{"missing"}); len(entries) != 0 {
+		t.Errorf("history of a missing key = %+v", entries)
+	}
+	if entries, err := loadKeyHistory(projectLocation{Store: t.TempDir(), Project: "new"}, []string{"db"}); err != nil || entries != nil {
+		t.Errorf("history of a new project = %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// importGoCommand creates a key per package, type and function of a Go
+// package from its doc comments: infoShort is the first sentence, infoLong
+// the whole comment and example the signature or an Example* test function
+func importGoCommand(settings *Settings, args []string) {
+	args, dryRun := extractFlag(args, "--dry-run")
+	args, all := extractFlag(args, "--all")
+	args, project, err := extractOption(args, "--project")
+	if err != nil || len(args) != 1 || project == "" {
+		fmt.Println("[ERROR] Usage: recall import go <package dir> --project <project> [--all] [--dry-run]")
+		os.Exit(1)
+	}
+
+	keys, err := parseGoPackage(args[0], all)
+	if err != nil {
+		fmt.Printf("[ERROR] Could not parse Go package in %s: %v\n", args[0], err)
+		return
+	}
+
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	projectData := loadProjectData(projectFile)
+
+	for i := range keys {
+		if keys[i].source.File == "" {
+			continue
+		}
+		if keys[i].source.File, err = sourceReference(projectFile, keys[i].source.File); err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+	}
+
+	changed := mergeGeneratedKeys(projectFile, projectData, keys, dryRun)
+	if changed == 0 {
+		fmt.Printf("[INFO] %s is already up to date\n", projectFile.Path)
+		return
+	}
+	if dryRun {
+		fmt.Printf("[INFO] Dry run: %d key(s) would change in %s\n", changed, projectFile.Path)
+		return
+	}
+	if err := saveProjectData(projectFile, projectData); err != nil {
+		fmt.Printf("[ERROR] Error saving %s: %v\n", projectFile.Path, err)
+		return
+	}
+	fmt.Printf("[INFO] Imported %d key(s) into %s\n", changed, projectFile.Path)
+}
+
+// mergeGeneratedKeys stores generated keys in projectData. A hash of the
+// generated text is remembered in a "generated" field of each key, so a
+// re-run only replaces fields that are empty or still hold the previously
+// generated text, and leaves hand-written fields alone. It returns the
+// number of changed keys.
+//
+// Keys also reference the declaration they were generated from (see --stale).
+// Its hash is only refreshed when no hand-written field remains, so those
+// keep showing up as stale until they are reviewed.
+func mergeGeneratedKeys(location projectLocation, projectData ProjectData, keys []importedKey, dryRun bool) int {
+	changed := 0
+	for _, key := range keys {
+		path := keyPathString(key.keyPath)
+		node, exists := getKeyNode(projectData, path)
+		current := getKeyData(projectData, path)
+		previous := generatedHashes(node)
+		hashes := make(map[string]string)
+		for _, name := range editSections {
+			hashes[name] = hashGeneratedText(*keyDataField(&key.data, name))
+		}
+
+		// pending holds the generated text that changed under hand-written fields
+		merged, pending := current, current
+		for _, name := range editSections {
+			field, generated := keyDataField(&merged, name), *keyDataField(&key.data, name)
+			if *field == "" || hashGeneratedText(*field) == previous[name] {
+				*field = generated
+			} else if hashes[name] != previous[name] {
+				*keyDataField(&pending, name) = generated
+			}
+		}
+		source, hasSource := readKeySource(node)
+		sourceChanged := key.source.File != "" && (!hasSource || source.File != key.source.File || source.Symbol != key.source.Symbol)
+		if exists && merged == current && reflect.DeepEqual(previous, hashes) && !sourceChanged {
+			continue
+		}
+		changed++
+		reportKeyChange(key.keyPath, exists, current, merged)
+		if pending != current {
+			fmt.Println("  generated text that changed, not applied over hand-written fields:")
+			printKeyDataDiff(current, pending)
+		}
+		if sourceChanged {
+			fmt.Printf("  source: %s %s\n", key.source.File, key.source.Symbol)
+		}
+
+		if !dryRun {
+			setKeyData(projectData, path, merged)
+			generated := make(map[string]interface{})
+			for name, hash := range hashes {
+				generated[name] = hash
+			}
+			ensureKeyNode(projectData, path)["generated"] = generated
+			if key.source.File != "" {
+				if sourceChanged {
+					writeKeySource(ensureKeyNode(projectData, path), key.source)
+				}
+				if merged == key.data || sourceChanged {
+					refreshSourceHash(location, projectData, path)
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// generatedHashes returns the hash of the generated text of each field of a
+// key node
+func generatedHashes(node map[string]interface{}) map[string]string {
+	fields, _ := toStringMap(node["generated"])
+	hashes := make(map[string]string)
+	for _, name := range editSections {
+		hashes[name], _ = fields[name].(string)
+	}
+	return hashes
+}
+
+// hashGeneratedText returns the hash stored for a generated field
+func hashGeneratedText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// parseGoPackage parses the Go package in dir, including Example functions
+// from its test files. Unexported declarations are included for package main
+// or if all is set.
+func parseGoPackage(dir string, all bool) ([]importedKey, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	filesByPackage := make(map[string][]*ast.File)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		filesByPackage[file.Name.Name] = append(filesByPackage[file.Name.Name], file)
+	}
+
+	// The package with the most files wins, external _test packages only add examples
+	name := ""
+	for pkgName, files := range filesByPackage {
+		if !strings.HasSuffix(pkgName, "_test") && (name == "" || len(files) > len(filesByPackage[name])) {
+			name = pkgName
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no Go files found")
+	}
+	files := append(filesByPackage[name], filesByPackage[name+"_test"]...)
+
+	mode := doc.Mode(0)
+	if all || name == "main" {
+		mode = doc.AllDecls
+	}
+	pkg, err := doc.NewFromFiles(fset, files, name, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []importedKey{{
+		keyPath: []string{pkg.Name},
+		data:    goKeyData(pkg, pkg.Doc, "", pkg.Examples, fset),
+	}}
+	for _, fn := range pkg.Funcs {
+		keys = append(keys, importedKey{
+			keyPath: []string{pkg.Name, fn.Name},
+			data:    goKeyData(pkg, fn.Doc, goFuncSignature(fset, fn.Decl), fn.Examples, fset),
+			source:  keySource{File: fset.Position(fn.Decl.Pos()).Filename, Symbol: fn.Name},
+		})
+	}
+	for _, typ := range pkg.Types {
+		keys = append(keys, importedKey{
+			keyPath: []string{pkg.Name, typ.Name},
+			data:    goKeyData(pkg, typ.Doc, goTypeDeclaration(fset, typ.Decl), typ.Examples, fset),
+			source:  keySource{File: fset.Position(typ.Decl.Pos()).Filename, Symbol: typ.Name},
+		})
+		// Constructors and methods are listed below their type
+		for _, fn := range typ.Funcs {
+			keys = append(keys, importedKey{
+				keyPath: []string{pkg.Name, typ.Name, fn.Name},
+				data:    goKeyData(pkg, fn.Doc, goFuncSignature(fset, fn.Decl), fn.Examples, fset),
+				source:  keySource{File: fset.Position(fn.Decl.Pos()).Filename, Symbol: fn.Name},
+			})
+		}
+		for _, fn := range typ.Methods {
+			keys = append(keys, importedKey{
+				keyPath: []string{pkg.Name, typ.Name, fn.Name},
+				data:    goKeyData(pkg, fn.Doc, goFuncSignature(fset, fn.Decl), fn.Examples, fset),
+				source:  keySource{File: fset.Position(fn.Decl.Pos()).Filename, Symbol: typ.Name + "." + fn.Name},
+			})
+		}
+	}
+	return keys, nil
+}
+
+// goKeyData builds KeyData from a doc comment, preferring the code of an
+// Example function over the signature
+func goKeyData(pkg *doc.Package, comment, signature string, examples []*doc.Example, fset *token.FileSet) KeyData {
+	data := KeyData{
+		InfoShort: pkg.Synopsis(comment),
+		InfoLong:  strings.TrimSpace(comment),
+		Example:   signature,
+	}
+	if len(examples) > 0 {
+		data.Example = goExampleCode(fset, examples[0])
+	}
+	return data
+}
+
+// goFuncSignature prints a function declaration without its body and doc comment
+func goFuncSignature(fset *token.FileSet, decl *ast.FuncDecl) string {
+	if decl == nil {
+		return ""
+	}
+	signature := *decl
+	signature.Doc = nil
+	signature.Body = nil
+	return printGoNode(fset, &signature)
+}
+
+// goTypeDeclaration prints a type declaration without its doc comment
+func goTypeDeclaration(fset *token.FileSet, decl *ast.GenDecl) string {
+	if decl == nil {
+		return ""
+	}
+	declaration := *decl
+	declaration.Doc = nil
+	return printGoNode(fset, &declaration)
+}
+
+// goExampleCode prints the body of an Example function without its braces
+func goExampleCode(fset *token.FileSet, example *doc.Example) string {
+	code := printGoNode(fset, example.Code)
+	if _, ok := example.Code.(*ast.BlockStmt); !ok {
+		return code
+	}
+
+	lines := strings.Split(code, "\n")
+	if len(lines) < 2 {
+		return code
+	}
+	lines = lines[1 : len(lines)-1]
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "\t")
+	}
+	if example.Output != "" {
+		lines = append(lines, "// Output:")
+		for _, line := range strings.Split(strings.TrimSpace(example.Output), "\n") {
+			lines = append(lines, "// "+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func printGoNode(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
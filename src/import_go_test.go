@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const importGoTestSource = `// Package shop sells things.
+package shop
+
+// Price returns the price of an item in cents.
+func Price(item string) int { return 0 }
+`
+
+func importGoTestKeys(t *testing.T, dir, source string) []importedKey {
+	t.Helper()
+	writeTestFile(t, filepath.Join(dir, "shop.go"), source)
+	keys, err := parseGoPackage(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestMergeGeneratedKeysStoresHashes(t *testing.T) {
+	dir := t.TempDir()
+	location := projectLocation{Store: filepath.Join(dir, ".recall"), Project: "shop", Backend: yamlBackend{}}
+	projectData := make(ProjectData)
+
+	keys := importGoTestKeys(t, dir, importGoTestSource)
+	captureOutput(t, func() { mergeGeneratedKeys(location, projectData, keys, false) })
+	if got := getKeyData(projectData, "shop.keys.Price").InfoShort; got != "Price returns the price of an item in cents." {
+		t.Fatalf("infoShort = %q", got)
+	}
+	node, _ := getKeyNode(projectData, "shop.keys.Price")
+	generated, _ := toStringMap(node["generated"])
+	data := getKeyData(projectData, "shop.keys.Price")
+	for _, name := range editSections {
+		if value, _ := generated[name].(string); value != hashGeneratedText(*keyDataField(&data, name)) {
+			t.Errorf("generated %s = %q, want the hash of the text", name, value)
+		}
+	}
+
+	// An unchanged package changes nothing
+	var changed int
+	captureOutput(t, func() { changed = mergeGeneratedKeys(location, projectData, keys, false) })
+	if changed != 0 {
+		t.Errorf("re-import changed %d keys, want 0", changed)
+	}
+
+	// A hand-written infoShort survives a changed doc comment, which is shown
+	setKeyData(projectData, "shop.keys.Price", KeyData{InfoShort: "Hand-written", InfoLong: getKeyData(projectData, "shop.keys.Price").InfoLong})
+	keys = importGoTestKeys(t, dir, strings.Replace(importGoTestSource, "in cents", "in euros", 1))
+	out := captureOutput(t, func() { changed = mergeGeneratedKeys(location, projectData, keys, false) })
+	if changed != 1 {
+		t.Errorf("changed %d keys, want 1", changed)
+	}
+	data = getKeyData(projectData, "shop.keys.Price")
+	if data.InfoShort != "Hand-written" || data.InfoLong != "Price returns the price of an item in euros." {
+		t.Errorf("merged %+v", data)
+	}
+	for _, want := range []string{"- Hand-written", "+ Price returns the price of an item in euros."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+}
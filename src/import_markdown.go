@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// markdownHeading matches ATX headings such as "## Database"
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// markdownSection is the content below a heading, up to the next heading
+type markdownSection struct {
+	level   int
+	title   string
+	content []string
+}
+
+// markdownReservedNames are keys with a meaning of their own in a project
+// file. Headings with these names get a number, like repeated headings.
+var markdownReservedNames = map[string]bool{
+	"info":       true,
+	"keys":       true,
+	"include":    true,
+	journalField: true,
+}
+
+// importedKey is a key parsed from an import source
+type importedKey struct {
+	keyPath []string
+	data    KeyData
+	source  keySource // code the key documents, if known
+}
+
+// importCommand handles "recall import <format> ..."
+func importCommand(settings *Settings, args []string) {
+	if len(args) == 0 {
+		fmt.Println("[ERROR] Usage: recall import markdown|go <source> --project <project> [--dry-run]")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "markdown":
+		importMarkdownCommand(settings, args[1:])
+	case "go":
+		importGoCommand(settings, args[1:])
+	default:
+		fmt.Printf("[ERROR] Unknown import format '%s'\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// importMarkdownCommand maps a Markdown document onto a project: heading levels
+// become the nested key hierarchy, the first paragraph of a section its
+// infoShort, the first fenced code block its example and the remaining prose its infoLong
+func importMarkdownCommand(settings *Settings, args []string) {
+	args, dryRun := extractFlag(args, "--dry-run")
+	args, project, err := extractOption(args, "--project")
+	if err != nil || len(args) != 1 || project == "" {
+		fmt.Println("[ERROR] Usage: recall import markdown <file> --project <project> [--dry-run]")
+		os.Exit(1)
+	}
+
+	content, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Printf("[ERROR] Could not read %s: %v\n", args[0], err)
+		return
+	}
+
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	projectData := loadProjectData(projectFile)
+
+	keys := parseMarkdownDocument(string(content))
+	if len(keys) == 0 {
+		fmt.Printf("[INFO] Nothing to import from %s\n", args[0])
+		return
+	}
+	changed := mergeImportedKeys(projectData, keys, dryRun)
+
+	if changed == 0 {
+		fmt.Printf("[INFO] %s is already up to date\n", projectFile.Path)
+		return
+	}
+	if dryRun {
+		fmt.Printf("[INFO] Dry run: %d key(s) would change in %s\n", changed, projectFile.Path)
+		return
+	}
+	if err := saveProjectData(projectFile, projectData); err != nil {
+		fmt.Printf("[ERROR] Error saving %s: %v\n", projectFile.Path, err)
+		return
+	}
+	fmt.Printf("[INFO] Imported %d key(s) into %s\n", changed, projectFile.Path)
+}
+
+// mergeImportedKeys merges keys into projectData with setKeyData. Fields the
+// import leaves empty keep their current value. Every change is printed as a
+// diff; with dryRun projectData is left untouched. It returns the number of changed keys.
+func mergeImportedKeys(projectData ProjectData, keys []importedKey, dryRun bool) int {
+	changed := 0
+	for _, key := range keys {
+		path := keyPathString(key.keyPath)
+		_, exists := getKeyNode(projectData, path)
+		current := getKeyData(projectData, path)
+
+		merged := current
+		if key.data.InfoShort != "" {
+			merged.InfoShort = key.data.InfoShort
+		}
+		if key.data.InfoLong != "" {
+			merged.InfoLong = key.data.InfoLong
+		}
+		if key.data.Example != "" {
+			merged.Example = key.data.Example
+		}
+		if exists && merged == current {
+			continue
+		}
+		changed++
+		reportKeyChange(key.keyPath, exists, current, merged)
+
+		if !dryRun {
+			setKeyData(projectData, path, merged)
+		}
+	}
+	return changed
+}
+
+// reportKeyChange prints a key that an import adds (+) or changes (~)
+// together with the diff of its fields
+func reportKeyChange(keyPath []string, exists bool, old, new KeyData) {
+	label := strings.Join(keyPath, " → ")
+	if len(keyPath) == 0 {
+		label = "(general info)"
+	}
+	if exists {
+		fmt.Printf("\033[1;33m~ %s\033[0m\n", label)
+	} else {
+		fmt.Printf("\033[1;32m+ %s\033[0m\n", label)
+	}
+	printKeyDataDiff(old, new)
+}
+
+// parseMarkdownDocument splits a Markdown document into keys. Text before the
+// first heading is the general project info, as is a single top-level title
+// heading that comes first (as in most READMEs).
+func parseMarkdownDocument(content string) []importedKey {
+	sections := splitMarkdownSections(content)
+
+	// sections[0] is the preamble before the first heading
+	info := sections[0].content
+	headings := sections[1:]
+	if len(headings) > 0 {
+		titleCount := 0
+		for _, section := range headings {
+			if section.level <= headings[0].level {
+				titleCount++
+			}
+		}
+		if titleCount == 1 && len(headings) > 1 {
+			info = append(info, headings[0].content...)
+			headings = headings[1:]
+		}
+	}
+
+	var keys []importedKey
+	if data := markdownKeyData(info); data != (KeyData{}) {
+		keys = append(keys, importedKey{keyPath: []string{}, data: data})
+	}
+
+	type parent struct {
+		level   int
+		keyPath []string
+		used    map[string]bool
+	}
+	stack := []parent{{level: 0, keyPath: []string{}, used: map[string]bool{}}}
+	for _, section := range headings {
+		for len(stack) > 1 && stack[len(stack)-1].level >= section.level {
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+
+		name := markdownKeyName(section.title)
+		for i := 2; top.used[name] || markdownReservedNames[name]; i++ {
+			name = fmt.Sprintf("%s%d", markdownKeyName(section.title), i)
+		}
+		top.used[name] = true
+
+		keyPath := append(append([]string{}, top.keyPath...), name)
+		keys = append(keys, importedKey{keyPath: keyPath, data: markdownKeyData(section.content)})
+		stack = append(stack, parent{level: section.level, keyPath: keyPath, used: map[string]bool{}})
+	}
+	return keys
+}
+
+// splitMarkdownSections splits content at its headings, ignoring anything
+// that looks like a heading inside fenced code blocks
+func splitMarkdownSections(content string) []markdownSection {
+	sections := []markdownSection{{}}
+	fence := ""
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if match := markdownHeading.FindStringSubmatch(line); match != nil {
+				sections = append(sections, markdownSection{level: len(match[1]), title: match[2]})
+				continue
+			}
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+			}
+		} else if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			fence = ""
+		}
+		last := &sections[len(sections)-1]
+		last.content = append(last.content, line)
+	}
+	return sections
+}
+
+// markdownKeyData converts the lines of a section into KeyData
+func markdownKeyData(lines []string) KeyData {
+	var data KeyData
+	var paragraphs []string
+	var current []string
+	fence := ""
+
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(current, "\n")); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		current = nil
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			flush()
+			fence = trimmed[:3]
+			current = append(current, line)
+			continue
+		}
+		if fence != "" {
+			current = append(current, line)
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" && len(current) > 1 {
+				block := strings.Join(current, "\n")
+				current = nil
+				fence = ""
+				if data.Example == "" {
+					data.Example = parseMarkdownFence(block)
+				} else {
+					paragraphs = append(paragraphs, block)
+				}
+			}
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	if len(paragraphs) > 0 && !strings.HasPrefix(paragraphs[0], "```") && !strings.HasPrefix(paragraphs[0], "~~~") {
+		data.InfoShort = paragraphs[0]
+		if !strings.HasPrefix(data.InfoShort, "- ") && !strings.HasPrefix(data.InfoShort, "* ") {
+			// Unwrap hard-wrapped prose, but keep lists one item per line
+			data.InfoShort = strings.Join(strings.Fields(data.InfoShort), " ")
+		}
+		paragraphs = paragraphs[1:]
+	}
+	data.InfoLong = strings.Join(paragraphs, "\n\n")
+	return data
+}
+
+// markdownKeyName turns a heading into a key name in the lowerCamelCase style
+// used by the bundled projects, e.g. "Basic Usage" becomes "basicUsage"
+func markdownKeyName(title string) string {
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for i, word := range words {
+		runes := []rune(word)
+		if i == 0 {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		sb.WriteString(string(runes))
+	}
+	if sb.Len() == 0 {
+		return "section"
+	}
+	return sb.String()
+}
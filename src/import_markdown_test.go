@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const importMarkdownTestDocument = "# My App\n" +
+	"\n" +
+	"A small web shop.\n" +
+	"\n" +
+	"## Basic Usage\n" +
+	"\n" +
+	"Start it with make.\n" +
+	"\n" +
+	"```bash\n" +
+	"make run\n" +
+	"# not a heading\n" +
+	"```\n" +
+	"\n" +
+	"### Options\n" +
+	"\n" +
+	"Flags of the server.\n" +
+	"\n" +
+	"### Options\n" +
+	"\n" +
+	"More flags.\n" +
+	"\n" +
+	"## Info\n" +
+	"\n" +
+	"Contact the team.\n" +
+	"\n" +
+	"## Keys\n" +
+	"\n" +
+	"API keys live in the vault.\n" +
+	"\n" +
+	"### Include\n" +
+	"\n" +
+	"Not an include list.\n" +
+	"\n" +
+	"### Journal\n" +
+	"\n" +
+	"Not a journal.\n"
+
+func TestParseMarkdownDocument(t *testing.T) {
+	keys := parseMarkdownDocument(importMarkdownTestDocument)
+
+	want := []struct {
+		path      string
+		infoShort string
+	}{
+		{"", "A small web shop."},
+		{"basicUsage", "Start it with make."},
+		{"basicUsage options", "Flags of the server."},
+		{"basicUsage options2", "More flags."},
+		{"info2", "Contact the team."},
+		{"keys2", "API keys live in the vault."},
+		{"keys2 include2", "Not an include list."},
+		{"keys2 journal2", "Not a journal."},
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("parsed %d keys, want %d: %+v", len(keys), len(want), keys)
+	}
+	for i, w := range want {
+		if path := strings.Join(keys[i].keyPath, " "); path != w.path || keys[i].data.InfoShort != w.infoShort {
+			t.Errorf("key %d = %q %q, want %q %q", i, path, keys[i].data.InfoShort, w.path, w.infoShort)
+		}
+	}
+	if example := keys[1].data.Example; example != "make run\n# not a heading" {
+		t.Errorf("example of basicUsage = %q", example)
+	}
+
+	// Without a single title heading, the headings are the top-level keys
+	keys = parseMarkdownDocument("intro\n\n# One\n\none\n\n# Two\n\ntwo\n")
+	if len(keys) != 3 || keys[0].data.InfoShort != "intro" || keys[1].keyPath[0] != "one" || keys[2].keyPath[0] != "two" {
+		t.Errorf("parsed %+v", keys)
+	}
+}
+
+func TestMarkdownKeyData(t *testing.T) {
+	tests := []struct {
+		lines string
+		want  KeyData
+	}{
+		{"", KeyData{}},
+		{"Hard\nwrapped   text.\n\nMore.\n\nEven more.", KeyData{InfoShort: "Hard wrapped text.", InfoLong: "More.\n\nEven more."}},
+		{"- one\n- two\n\nAfter the list.", KeyData{InfoShort: "- one\n- two", InfoLong: "After the list."}},
+		{"```go\nx := 1\n\ny := 2\n```\n\nText.", KeyData{InfoShort: "Text.", Example: "x := 1\n\ny := 2"}},
+		{"Intro.\n\n```\nfirst\n```\n\n```\nsecond\n```", KeyData{InfoShort: "Intro.", InfoLong: "```\nsecond\n```", Example: "first"}},
+	}
+	for _, tt := range tests {
+		if got := markdownKeyData(strings.Split(tt.lines, "\n")); got != tt.want {
+			t.Errorf("markdownKeyData(%q) = %+v, want %+v", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownKeyName(t *testing.T) {
+	tests := map[string]string{
+		"Basic Usage":         "basicUsage",
+		"Step 2: Deploy":      "step2Deploy",
+		"Über die App":        "überDieApp",
+		"--- !":               "section",
+		"configuration files": "configurationFiles",
+	}
+	for title, want := range tests {
+		if got := markdownKeyName(title); got != want {
+			t.Errorf("markdownKeyName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestMergeImportedKeysDryRun(t *testing.T) {
+	projectData := parseTestYAML(t, "basicUsage:\n  infoShort: old\n  example: make run\n")
+	keys := []importedKey{
+		{keyPath: []string{"basicUsage"}, data: KeyData{InfoShort: "new", Example: "make run"}},
+		{keyPath: []string{"basicUsage", "options"}, data: KeyData{InfoShort: "flags"}},
+		{keyPath: []string{"empty"}, data: KeyData{}},
+	}
+
+	var changed int
+	out := captureOutput(t, func() { changed = mergeImportedKeys(projectData, keys, true) })
+	if changed != 3 {
+		t.Errorf("dry run counted %d changes, want 3", changed)
+	}
+	if !strings.Contains(out, "~ basicUsage") || !strings.Contains(out, "+ basicUsage → options") {
+		t.Errorf("dry run output:\n%s", out)
+	}
+	if got := getKeyData(projectData, "basicUsage").InfoShort; got != "old" {
+		t.Errorf("dry run changed infoShort to %q", got)
+	}
+	if _, exists := getKeyNode(projectData, "basicUsage.keys.options"); exists {
+		t.Error("dry run created basicUsage → options")
+	}
+
+	captureOutput(t, func() { changed = mergeImportedKeys(projectData, keys, false) })
+	if data := getKeyData(projectData, "basicUsage"); changed != 3 || data.InfoShort != "new" || data.Example != "make run" {
+		t.Errorf("merge changed %d keys, basicUsage = %+v", changed, data)
+	}
+	captureOutput(t, func() { changed = mergeImportedKeys(projectData, keys, false) })
+	if changed != 0 {
+		t.Errorf("second merge changed %d keys, want 0", changed)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// journalField holds the dated entries of logbook keys like "decisions"
+const journalField = "journal"
+
+// journalPreview is the number of entries showKey prints without --all
+const journalPreview = 5
+
+// journalEntry is one dated note in the journal of a key
+type journalEntry struct {
+	Time string `yaml:"time"`
+	User string `yaml:"user,omitempty"`
+	Text string `yaml:"text"`
+}
+
+// readJournal returns the journal entries of a key node, oldest first
+func readJournal(node map[string]interface{}) []journalEntry {
+	list, _ := node[journalField].([]interface{})
+	var entries []journalEntry
+	for _, item := range list {
+		fields, ok := toStringMap(item)
+		if !ok {
+			continue
+		}
+		var entry journalEntry
+		entry.Time, _ = fields["time"].(string)
+		entry.User, _ = fields["user"].(string)
+		entry.Text, _ = fields["text"].(string)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// appendJournalEntry adds entry at the end of the journal of a key node
+func appendJournalEntry(node map[string]interface{}, entry journalEntry) {
+	list, _ := node[journalField].([]interface{})
+	fields := map[string]interface{}{"time": entry.Time, "text": entry.Text}
+	if entry.User != "" {
+		fields["user"] = entry.User
+	}
+	node[journalField] = append(list, fields)
+}
+
+// addedJournalEntries returns the entries appended to the journal of a key
+// between two versions of its node
+func addedJournalEntries(old, new map[string]interface{}) []journalEntry {
+	before, after := readJournal(old), readJournal(new)
+	if len(after) <= len(before) {
+		return nil
+	}
+	return after[len(before):]
+}
+
+// printJournal prints the latest journal entries of a key node, or all of
+// them. It reports whether there were any.
+func printJournal(node map[string]interface{}, all bool) bool {
+	entries := readJournal(node)
+	if len(entries) == 0 {
+		return false
+	}
+
+	fmt.Println()
+	fmt.Printf("\033[1;32m#############################\033[0m\n")
+	fmt.Printf("\033[1;32mJournal:\033[0m\n")
+	shown := entries
+	if !all && len(entries) > journalPreview {
+		shown = entries[len(entries)-journalPreview:]
+		fmt.Printf("\033[2m(%d older entries, use --all to show them)\033[0m\n", len(entries)-journalPreview)
+	}
+	for _, entry := range shown {
+		stamp := entry.Time
+		if t, err := time.Parse(time.RFC3339, entry.Time); err == nil {
+			stamp = t.Local().Format("2006-01-02 15:04")
+		}
+		lines := strings.Split(entry.Text, "\n")
+		fmt.Printf("\033[1;33m%s\033[0m  %s\n", stamp, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Printf("%s  %s\n", strings.Repeat(" ", len(stamp)), line)
+		}
+	}
+	return true
+}
+
+// journalCommand handles `recall log <project> [key...] ["text"|-]`, appending
+// a timestamped entry to the journal of a key. Like for add, piped input is the text.
+func journalCommand(settings *Settings, args []string) {
+	if len(args) == 0 {
+		fmt.Println("[ERROR] Usage: recall log <project> [key...] [\"text\"|-]")
+		os.Exit(1)
+	}
+	args, text, err := readInputText(args)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		os.Exit(1)
+	}
+	project, keyPath := args[0], args[1:]
+
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	projectData := loadProjectData(projectFile)
+
+	node := ensureKeyNode(projectData, keyPathString(keyPath))
+	entry := journalEntry{Time: time.Now().Format(time.RFC3339), User: currentUser(), Text: text}
+	appendJournalEntry(node, entry)
+	if err := saveProjectData(projectFile, projectData); err != nil {
+		fmt.Printf("[ERROR] Error saving %s: %v\n", projectFile.Path, err)
+		return
+	}
+
+	label := strings.Join(keyPath, " → ")
+	if len(keyPath) == 0 {
+		label = "general info"
+	}
+	fmt.Printf("[INFO] Added journal entry %d of %s in %s\n", len(readJournal(node)), label, projectFile.Path)
+}
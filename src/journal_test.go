@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJournalEntries(t *testing.T) {
+	old := map[string]interface{}{}
+	node := map[string]interface{}{"infoShort": "decisions"}
+	appendJournalEntry(node, journalEntry{Time: "1", User: "me", Text: "one"})
+	appendJournalEntry(node, journalEntry{Time: "2", Text: "two"})
+
+	entries := readJournal(node)
+	if len(entries) != 2 || entries[0] != (journalEntry{Time: "1", User: "me", Text: "one"}) || entries[1].Text != "two" {
+		t.Errorf("journal = %+v", entries)
+	}
+	if added := addedJournalEntries(old, node); len(added) != 2 {
+		t.Errorf("added entries = %+v", added)
+	}
+	if added := addedJournalEntries(node, node); added != nil {
+		t.Errorf("added entries of an unchanged journal = %+v", added)
+	}
+
+	// Entries parsed from YAML, skipping items that are not entries
+	projectData := parseTestYAML(t, "log:\n  journal:\n  - time: \"1\"\n    text: one\n  - broken\n")
+	logNode, _ := getKeyNode(projectData, "log")
+	if entries := readJournal(logNode); len(entries) != 1 || entries[0].Text != "one" {
+		t.Errorf("journal read from YAML = %+v", entries)
+	}
+}
+
+func TestPrintJournal(t *testing.T) {
+	node := map[string]interface{}{}
+	for i := 1; i <= journalPreview+2; i++ {
+		appendJournalEntry(node, journalEntry{Time: "t", Text: "entry " + string(rune('0'+i))})
+	}
+	latest := captureOutput(t, func() { printJournal(node, false) })
+	if strings.Contains(latest, "entry 1\n") || !strings.Contains(latest, "entry 7") {
+		t.Errorf("latest entries:\n%s", latest)
+	}
+	all := captureOutput(t, func() { printJournal(node, true) })
+	if !strings.Contains(all, "entry 1") {
+		t.Errorf("all entries:\n%s", all)
+	}
+	if printJournal(map[string]interface{}{}, true) {
+		t.Error("a key without journal reports entries")
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"os/exec"
 	"io/ioutil"
 	"gopkg.in/yaml.v2"
 )
@@ -19,6 +18,12 @@ func main() {
 
 	args := os.Args[1:] // Skip the program name
 
+	// Completion gets the words as typed, including a trailing --edit
+	if len(args) > 0 && args[0] == "__complete" {
+		completeCommand(settings, args[1:])
+		return
+	}
+
 	// Check if --edit flag is at the end
 	editMode := false
 	if len(args) > 1 && args[len(args)-1] == "--edit" {
@@ -26,6 +31,161 @@ func main() {
 		args = args[:len(args)-1] // Remove --edit from the end
 	}
 
+	// Commands working across all projects of the stores
+	if len(args) > 0 {
+		switch args[0] {
+		case "--list":
+			if editMode || len(args) > 2 {
+				fmt.Println("[ERROR] Usage: recall --list [namespace/]")
+				os.Exit(1)
+			}
+			namespace := ""
+			if len(args) == 2 {
+				namespace = args[1]
+			}
+			listCommand(settings, namespace)
+			return
+		case "--search":
+			if editMode || len(args) < 2 || len(args) > 3 {
+				fmt.Println("[ERROR] Usage: recall --search <text> [namespace/]")
+				os.Exit(1)
+			}
+			namespace := ""
+			if len(args) == 3 {
+				namespace = args[2]
+			}
+			searchCommand(settings, args[1], namespace)
+			return
+		case "--migrate":
+			if editMode || len(args) < 2 || len(args) > 3 {
+				fmt.Println("[ERROR] Usage: recall --migrate <from>-to-<to> [local|global]")
+				os.Exit(1)
+			}
+			store := ""
+			if len(args) == 3 {
+				store = args[2]
+			}
+			migrateCommand(settings, args[1], store)
+			return
+		case "import":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with import")
+				os.Exit(1)
+			}
+			importCommand(settings, args[1:])
+			return
+		case "export":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with export")
+				os.Exit(1)
+			}
+			exportCommand(settings, args[1:])
+			return
+		case "--source":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with --source")
+				os.Exit(1)
+			}
+			sourceCommand(settings, args[1:])
+			return
+		case "--stale":
+			if editMode || len(args) > 2 {
+				fmt.Println("[ERROR] Usage: recall --stale [project]")
+				os.Exit(1)
+			}
+			project := ""
+			if len(args) == 2 {
+				project = args[1]
+			}
+			if staleCommand(settings, project) {
+				os.Exit(1)
+			}
+			return
+		case "--history":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with --history")
+				os.Exit(1)
+			}
+			historyCommand(settings, args[1:])
+			return
+		case "--log":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with --log")
+				os.Exit(1)
+			}
+			logCommand(settings, args[1:])
+			return
+		case "sync":
+			if editMode || len(args) > 1 {
+				fmt.Println("[ERROR] Usage: recall sync")
+				os.Exit(1)
+			}
+			syncCommand(settings)
+			return
+		case "merge-driver":
+			mergeDriverCommand(args[1:])
+			return
+		case "add":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with add")
+				os.Exit(1)
+			}
+			addCommand(settings, args[1:])
+			return
+		case "log":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with log")
+				os.Exit(1)
+			}
+			journalCommand(settings, args[1:])
+			return
+		case "run":
+			if editMode {
+				fmt.Println("[ERROR] Cannot use --edit with run")
+				os.Exit(1)
+			}
+			runCommand(settings, args[1:])
+			return
+		case "completion":
+			completionCommand(args[1:])
+			return
+		case "-i", "--interactive":
+			if editMode || len(args) > 2 {
+				fmt.Println("[ERROR] Usage: recall -i [project]")
+				os.Exit(1)
+			}
+			project := ""
+			if len(args) == 2 {
+				project = args[1]
+			}
+			interactiveCommand(settings, project)
+			return
+		case "--undo":
+			if editMode || len(args) > 1 {
+				fmt.Println("[ERROR] Usage: recall --undo")
+				os.Exit(1)
+			}
+			undoCommand(settings)
+			return
+		}
+	}
+
+	// --field places the cursor at a field when editing
+	args, field, err := extractOption(args, "--field")
+	if err != nil || (field != "" && !editMode && (len(args) == 0 || args[0] != "--edit")) {
+		fmt.Println("[ERROR] Usage: recall --edit <project> [key...] --field <infoShort|infoLong|example>")
+		os.Exit(1)
+	}
+
+	// --all shows every journal entry of a key instead of the latest ones,
+	// --raw shows placeholders like {{project_root}} unexpanded
+	args, showAll := extractFlag(args, "--all")
+	args, raw := extractFlag(args, "--raw")
+	if (showAll || raw) && (editMode || (len(args) > 0 && args[0] == "--edit")) {
+		fmt.Println("[ERROR] Cannot use --all or --raw with --edit")
+		os.Exit(1)
+	}
+
 	// Handle different argument patterns
 	switch len(args) {
 	case 0:
@@ -56,9 +216,9 @@ func main() {
 			project := args[0]
 			keyPath := []string{}
 			if editMode {
-				editKey(settings, project, keyPath)
+				editKey(settings, project, keyPath, field)
 			} else {
-				showKey(settings, project, keyPath)	// Empty keyPath means general project info
+				showKey(settings, project, keyPath, showAll, raw)	// Empty keyPath means general project info
 			}
 		}
 	default:
@@ -77,15 +237,15 @@ func main() {
 			// recall --edit <project> <key> [nested keys...]
 			project := args[1]
 			keyPath := args[2:]
-			editKey(settings, project, keyPath)
+			editKey(settings, project, keyPath, field)
 		} else {
 			// recall <project> <key> [nested keys...]
 			project := args[0]
 			keyPath := args[1:]
 			if editMode {
-				editKey(settings, project, keyPath)
+				editKey(settings, project, keyPath, field)
 			} else {
-				showKey(settings, project, keyPath)
+				showKey(settings, project, keyPath, showAll, raw)
 			}
 		}
 	}
@@ -101,9 +261,39 @@ func showUsage(settings *Settings) {
 	fmt.Println("  recall                                Show this help")
 	fmt.Println("  recall <project>                      Show general project info")
 	fmt.Println("  recall <project> <key>                Show specific key info")
+	fmt.Println("    --all                               Show every journal entry, not just the latest")
+	fmt.Println("    --raw                               Show placeholders like {{project_root}} unexpanded")
 	fmt.Println("  recall <project> <key> <subkey>...    Show nested key info")
 	fmt.Println("  recall --edit <project> <key>...      Edit specific key")
+	fmt.Println("    --field <name>                      Start the editor at infoShort, infoLong or example")
 	fmt.Println("  recall <project> <key>... --edit      Edit specific key (alternative)")
+	fmt.Println("  recall --list [namespace/]            List projects of all stores")
+	fmt.Println("  recall --search <text> [namespace/]   Search keys of all projects")
+	fmt.Println("  recall --migrate <from>-to-<to>       Convert a store, e.g. yaml-to-sqlite")
+	fmt.Println("  recall import markdown <file> --project <project> [--dry-run]")
+	fmt.Println("                                        Import headings of a Markdown file as keys")
+	fmt.Println("  recall import go <dir> --project <project> [--all] [--dry-run]")
+	fmt.Println("                                        Import doc comments of a Go package as keys")
+	fmt.Println("  recall export <project> --format md|html|man|json [--output <file>]")
+	fmt.Println("                                        Export a project as documentation")
+	fmt.Println("  recall --source <project> <key>... --file <file> [--symbol <name>]")
+	fmt.Println("                                        Link a key to the source code it documents")
+	fmt.Println("  recall --stale [project]              Report keys whose linked source changed")
+	fmt.Println("  recall --history <project> <key>...   List revisions of a key")
+	fmt.Println("         ... --diff <n> [m]             Show a revision or compare two")
+	fmt.Println("         ... --revert <n>               Restore a revision")
+	fmt.Println("  recall --log <project> [key...]       Show git commits of a project or key")
+	fmt.Println("  recall add <project> <key>... [text]  Add text (or piped input) to a key without an editor")
+	fmt.Println("  recall log <project> <key>... [text]  Add a timestamped journal entry to a key")
+	fmt.Println("  recall run <project> <key>...         Run the example of a key in a shell")
+	fmt.Println("         ... --var name=value           Fill in {{name}}, quoted as one shell word")
+	fmt.Println("         ... --unquoted                 Insert the values as shell code instead")
+	fmt.Println("  recall completion bash|zsh|fish       Print the shell completion script")
+	fmt.Println("  recall -i [project]                   Browse and edit projects in the terminal")
+	fmt.Println("  recall --undo                         Revert the most recent change made by recall")
+	fmt.Println("  recall sync                           Sync ~/.recall with the configured git remote")
+	fmt.Println("  recall merge-driver %O %A %B %P       Git merge driver for recall files")
+	fmt.Println("  recall merge-driver --install         Use it for ./.recall in this repository")
 	fmt.Println("  recall --init                         Initialize local recall")
 	fmt.Println("  recall --init-global                  Initialize global recall")
 	fmt.Println()
@@ -114,13 +304,16 @@ func showUsage(settings *Settings) {
 	fmt.Println("  recall myApp myClass myFunction myVariable")
 	fmt.Println("  recall --edit myApp deployment")
 	fmt.Println("  recall myApp deployment --edit")
+	fmt.Println("  recall work/api database")
+	fmt.Println("  recall --list work/")
+	fmt.Println("  recall --search postgres work/")
 	fmt.Println()
 	fmt.Printf("Settings: Editor=%s\n", settings.Editor)
 }
 
 func initLocal(settings *Settings) {
 	fmt.Println("[INFO] Initializing local recall directory at ./.recall/...")
-	if err := os.MkdirAll("./.recall", 0755); err != nil {
+	if err := os.MkdirAll(localStoreDir, 0755); err != nil {
 		fmt.Printf("[ERROR] Error creating ./.recall/ directory: %v\n", err)
 		return
 	}
@@ -129,8 +322,7 @@ func initLocal(settings *Settings) {
 
 func initGlobal(settings *Settings) {
 	// 1.) Check if ~/.recall/ directory exists
-	homeDir, _ := os.UserHomeDir()
-	globalPath := homeDir + "/.recall"
+	globalPath := globalStoreDir()
 	if _, err := os.Stat(globalPath); err == nil {
 		// Directory exists, no need to create it
 		fmt.Println("[INFO] Global recall directory already exists at " + globalPath)
@@ -144,7 +336,7 @@ func initGlobal(settings *Settings) {
 	}
 
 	// 3.) Check if settings.yaml exists in ~/.recall/
-	settingsFile := globalPath + "/settings.yaml"
+	settingsFile := settingsFilePath()
 	if _, err := os.Stat(settingsFile); err == nil {
 		// File exists, no need to create it
 		fmt.Println("[INFO] Global settings file already exists at " + settingsFile)
@@ -165,7 +357,14 @@ func initGlobal(settings *Settings) {
 	}
 }
 
-func showKey(settings *Settings, project string, keyPath []string) {
+func showKey(settings *Settings, project string, keyPath []string, showAll, raw bool) {
+	// Resolve the project file first so invalid names fail before any output
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+
 	var path string
 	if len(keyPath) == 0 {
 		fmt.Printf("Project: %s (general info)\n", project)
@@ -185,8 +384,7 @@ func showKey(settings *Settings, project string, keyPath []string) {
 		fmt.Printf("Project: %s, Key: %s\n", project, strings.Join(keyPath, " → "))
 	}
 
-	// 1.) Find project file and load existing data
-	projectFile := findProjectFile(project)
+	// 1.) Load existing data
 	projectData := loadProjectData(projectFile)
 	
 	// 2.) Check if project file exists
@@ -197,9 +395,10 @@ func showKey(settings *Settings, project string, keyPath []string) {
 	
 	// 3.) Get key data
 	keyData := getKeyData(projectData, path)
+	node, _ := getKeyNode(projectData, path)
 	
 	// 4.) Display the information
-	if keyData.InfoShort == "" && keyData.InfoLong == "" && keyData.Example == "" {
+	if keyData.InfoShort == "" && keyData.InfoLong == "" && keyData.Example == "" && len(readJournal(node)) == 0 {
 		if len(keyPath) == 0 {
 			fmt.Printf("[INFO] No general info found for project '%s'. Use --edit to add it.\n", project)
 		} else {
@@ -207,6 +406,9 @@ func showKey(settings *Settings, project string, keyPath []string) {
 		}
 		return
 	}
+	if !raw {
+		keyData = expandKeyData(keyData, templateVariables(projectFile, projectData))
+	}
 
 	if keyData.InfoShort != "" {
 		fmt.Println()
@@ -223,6 +425,10 @@ func showKey(settings *Settings, project string, keyPath []string) {
 		fmt.Printf("\033[1;32m#############################\033[0m\n")
 		fmt.Printf("\033[1;32mExample:\033[0m\n%s\n", keyData.Example)
 	}
+	printJournal(node, showAll)
+	if len(keyPath) == 0 {
+		printProjectVars(projectData)
+	}
 	
 	// 5.) Show available sub-keys if they exist
 	showSubKeys(projectData, path)
@@ -235,7 +441,7 @@ func showSubKeys(projectData ProjectData, keyPath string) {
 		// For "info" path, we want to show the root-level keys (excluding "info" itself)
 		current = make(map[string]interface{})
 		for k, v := range projectData {
-			if k != "info" { // Exclude the info section itself
+			if k != "info" && k != "include" { // Exclude the info section and include list
 				current[k] = v
 			}
 		}
@@ -299,7 +505,7 @@ func showSubKeys(projectData ProjectData, keyPath string) {
 		keysToShow = make(map[string]interface{})
 		for k, v := range current {
 			// Skip info fields at root level
-			if k != "infoShort" && k != "infoLong" && k != "example" {
+			if k != "infoShort" && k != "infoLong" && k != "example" && k != "include" {
 				keysToShow[k] = v
 			}
 		}
@@ -316,7 +522,25 @@ func showSubKeys(projectData ProjectData, keyPath string) {
 	//*/
 }
 
-func editKey(settings *Settings, project string, keyPath []string) {
+func editKey(settings *Settings, project string, keyPath []string, field string) {
+	// Resolve the project file first so invalid names fail before any output
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+
+	template, err := settingsEditTemplate(settings)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	if field != "" && keyDataField(&KeyData{}, field) == nil {
+		fmt.Printf("[ERROR] Unknown field '%s', use %s\n", field, strings.Join(editSections, ", "))
+		return
+	}
+	editor := resolveEditor(settings)
+
 	var path string
 	if len(keyPath) == 0 {
 		fmt.Printf("[INFO] Editing general info for project: %s\n", project)
@@ -334,47 +558,98 @@ func editKey(settings *Settings, project string, keyPath []string) {
 				path += ".keys." + keyPath[i]
 			}
 		}
-		fmt.Printf("[INFO] Editing project: %s, key: %s (using %s)\n", project, path, settings.Editor)
+		fmt.Printf("[INFO] Editing project: %s, key: %s (using %s)\n", project, path, editor)
 		fmt.Printf("[INFO] Edit the content between infoShort:, infoLong:, and example: sections\n")
 	}
 
-	// 1.) Find project file and load existing data
-	projectFile := findProjectFile(project)
+	// 1.) Load existing data
 	projectData := loadProjectData(projectFile)
 	
-	// 2.) Get current key data or create new entry
-	currentData := getKeyData(projectData, path)
+	// 2.) Let the user edit the key
+	editedData, changed := editKeyData(template, editor, projectData, project, keyPath, exampleLanguage(settings, projectData, path), field)
+	if !changed {
+		return
+	}
 	
-	// 3.) Create temporary YAML file with current key info
-	tempFile, err := createTempEditFile(currentData)
-	if err != nil {
-		fmt.Printf("[ERROR] Error creating temp file: %v\n", err)
+	// 3.) Update the project data and save, marking a referenced source as reviewed
+	setKeyData(projectData, path, editedData)
+	refreshSourceHash(projectFile, projectData, path)
+	if err := saveProjectData(projectFile, projectData); err != nil {
+		fmt.Printf("[ERROR] Error saving %s: %v\n", projectFile.Path, err)
 		return
 	}
-	defer os.Remove(tempFile) // Clean up temp file when done
 	
-	// 4.) Use settings.Editor to open the file
-	cmd := exec.Command(settings.Editor, tempFile)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	fmt.Printf("[INFO] Saved changes to %s\n", projectFile.Path)
+}
+
+// editKeyData opens the key at keyPath in the editor, asking first if it
+// doesn't exist yet. It returns the edited data and true if there is a change
+// to save; it returns false if the user declined a new key, the editor failed,
+// the file was emptied or nothing changed.
+func editKeyData(template editTemplate, editor editorCommand, projectData ProjectData, project string, keyPath []string, language, field string) (KeyData, bool) {
+	path := keyPathString(keyPath)
 	
-	if err := cmd.Run(); err != nil {
-		fmt.Printf("[ERROR] Error running editor: %v\n", err)
-		return
+	// 1.) Get current key data, asking before a new key is created so that
+	// a mistyped path doesn't silently add one
+	if _, exists := getKeyNode(projectData, path); !exists && path != "" {
+		if !confirm(fmt.Sprintf("Key '%s' does not exist in project '%s'. Create it?", strings.Join(keyPath, " "), project)) {
+			fmt.Println("[INFO] Nothing changed")
+			return KeyData{}, false
+		}
 	}
+	currentData := getKeyData(projectData, path)
 	
-	// 5.) Read the edited file back
-	editedData, err := parseEditedFile(tempFile)
+	// 2.) Create temporary file with current key info in the edit template
+	tempFile, fieldLines, err := createTempEditFile(template, currentData, language)
 	if err != nil {
-		fmt.Printf("[ERROR] Error parsing edited file: %v\n", err)
-		return
+		fmt.Printf("[ERROR] Error creating temp file: %v\n", err)
+		return KeyData{}, false
+	}
+	defer os.Remove(tempFile) // Clean up temp file when done
+	line := 1
+	if field != "" {
+		line = fieldLines[field]
 	}
 	
-	// 6.) Update the project data and save
-	setKeyData(projectData, path, editedData)
-	saveProjectData(projectFile, projectData)
-	
-	fmt.Printf("[INFO] Saved changes to %s\n", projectFile)
+	// 3.) Open the file in the editor, until the edited file is valid or
+	// the edit is aborted
+	var editedData KeyData
+	for {
+		if err := editor.run(tempFile, line); err != nil {
+			fmt.Printf("[ERROR] Error running editor: %v\n", err)
+			fmt.Println("[INFO] Nothing changed")
+			return KeyData{}, false
+		}
+		
+		// 4.) Read the edited file back. Like a git commit message, a file
+		// emptied of everything but comments aborts the edit
+		content, err := ioutil.ReadFile(tempFile)
+		if err != nil {
+			fmt.Printf("[ERROR] Error reading edited file: %v\n", err)
+			return KeyData{}, false
+		}
+		if template.empty(string(content)) {
+			fmt.Println("[INFO] Aborting edit due to empty file, nothing changed")
+			return KeyData{}, false
+		}
+		var problems []string
+		editedData, problems = template.parse(string(content))
+		if len(problems) == 0 {
+			break
+		}
+		for _, problem := range problems {
+			fmt.Printf("[WARN] %s\n", problem)
+		}
+		if err := ioutil.WriteFile(tempFile, []byte(template.annotate(string(content), problems)), 0600); err != nil {
+			fmt.Printf("[ERROR] Error updating edited file: %v\n", err)
+			return KeyData{}, false
+		}
+		line = 1 // show the problems
+	}
+	if editedData == trimKeyData(currentData) {
+		fmt.Println("[INFO] No changes made")
+		return KeyData{}, false
+	}
+	return editedData, true
 }
 
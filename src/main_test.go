@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// scriptEditor is an editor running a shell script on the edited file ($1)
+func scriptEditor(script string) editorCommand {
+	return editorCommand{Source: "test", Args: []string{"sh", "-c", script, "sh", "{file}"}}
+}
+
+func TestEditKeyData(t *testing.T) {
+	projectData := parseTestYAML(t, "db:\n  infoShort: old\n")
+	rewrite := scriptEditor(`printf 'infoShort:\nnew\n\ninfoLong:\n\nexample:\n' > "$1"`)
+
+	tests := []struct {
+		name    string
+		editor  editorCommand
+		keyPath []string
+		answer  string
+		changed bool
+		output  string
+	}{
+		{"changed", rewrite, []string{"db"}, "", true, ""},
+		{"unchanged", scriptEditor("true"), []string{"db"}, "", false, "No changes made"},
+		{"emptied", scriptEditor(`: > "$1"`), []string{"db"}, "", false, "Aborting edit due to empty file"},
+		{"only comments left", scriptEditor(`printf '# gone\n' > "$1"`), []string{"db"}, "", false, "Aborting edit due to empty file"},
+		{"editor failed", scriptEditor("exit 3"), []string{"db"}, "", false, "Error running editor"},
+		{"declined new key", rewrite, []string{"db", "pool"}, "n\n", false, "Nothing changed"},
+		{"accepted new key", rewrite, []string{"db", "pool"}, "y\n", true, "does not exist"},
+	}
+	for _, tt := range tests {
+		stdin := stdinReader
+		stdinReader = bufio.NewReader(strings.NewReader(tt.answer))
+
+		var data KeyData
+		var changed bool
+		out := captureOutput(t, func() {
+			data, changed = editKeyData(textTemplate{}, tt.editor, projectData, "app", tt.keyPath, "", "")
+		})
+		stdinReader = stdin
+
+		if changed != tt.changed || !strings.Contains(out, tt.output) {
+			t.Errorf("%s: changed = %v, output:\n%s", tt.name, changed, out)
+		}
+		if changed && data != (KeyData{InfoShort: "new"}) {
+			t.Errorf("%s: edited data %+v", tt.name, data)
+		}
+	}
+	if _, exists := getKeyNode(projectData, "db.keys.pool"); exists {
+		t.Error("editKeyData created the key itself")
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"gopkg.in/yaml.v2"
+)
+
+// mergeConflict is a value changed differently on both sides of a merge
+type mergeConflict struct {
+	Path   []string // raw path in the project data, e.g. [db keys conn infoShort]
+	Ours   interface{}
+	Theirs interface{}
+}
+
+// String names the key and field of a conflict, e.g. "db → conn (infoShort)"
+func (c mergeConflict) String() string {
+	var keys []string
+	field := ""
+	for i, segment := range c.Path {
+		if segment == "keys" && i > 0 {
+			continue
+		}
+		if i == len(c.Path)-1 && (segment == "infoShort" || segment == "infoLong" || segment == "example") {
+			field = segment
+			continue
+		}
+		keys = append(keys, segment)
+	}
+	label := strings.Join(keys, " → ")
+	if field != "" {
+		label += " (" + field + ")"
+	}
+	return label
+}
+
+// mergeProjectData performs a three-way merge of parsed project data. Maps
+// (projects, keys and their "keys" sections) are merged entry by entry, so
+// only a value changed differently on both sides is a conflict. resolve picks
+// the merged value of each conflict.
+func mergeProjectData(base, ours, theirs ProjectData, resolve func(conflict mergeConflict) interface{}) (ProjectData, []mergeConflict) {
+	var conflicts []mergeConflict
+	merged := mergeValues(normalizeValue(base), normalizeValue(ours), normalizeValue(theirs), nil, resolve, &conflicts)
+
+	result := make(ProjectData)
+	if m, ok := merged.(map[string]interface{}); ok {
+		for k, v := range m {
+			result[k] = v
+		}
+	}
+	return result, conflicts
+}
+
+// mergeValues merges normalized values; nil stands for a missing value
+func mergeValues(base, ours, theirs interface{}, path []string, resolve func(conflict mergeConflict) interface{}, conflicts *[]mergeConflict) interface{} {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours
+	case reflect.DeepEqual(base, ours):
+		return theirs
+	case reflect.DeepEqual(base, theirs):
+		return ours
+	}
+
+	oursMap, oursIsMap := ours.(map[string]interface{})
+	theirsMap, theirsIsMap := theirs.(map[string]interface{})
+	if oursIsMap && theirsIsMap {
+		baseMap, _ := base.(map[string]interface{})
+
+		keys := make(map[string]bool)
+		for _, m := range []map[string]interface{}{baseMap, oursMap, theirsMap} {
+			for k := range m {
+				keys[k] = true
+			}
+		}
+		names := make([]string, 0, len(keys))
+		for k := range keys {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		merged := make(map[string]interface{})
+		for _, k := range names {
+			value := mergeValues(baseMap[k], oursMap[k], theirsMap[k], append(append([]string{}, path...), k), resolve, conflicts)
+			if value != nil {
+				merged[k] = value
+			}
+		}
+		return merged
+	}
+
+	// Lists both sides appended to, such as journals, keep the entries of both
+	if merged, ok := mergeAppendedLists(base, ours, theirs); ok {
+		return merged
+	}
+
+	conflict := mergeConflict{Path: path, Ours: ours, Theirs: theirs}
+	*conflicts = append(*conflicts, conflict)
+	return resolve(conflict)
+}
+
+// mergeAppendedLists merges two lists that both extend the base list: the
+// items added by theirs follow those added by ours
+func mergeAppendedLists(base, ours, theirs interface{}) ([]interface{}, bool) {
+	baseList, _ := base.([]interface{})
+	oursList, oursIsList := ours.([]interface{})
+	theirsList, theirsIsList := theirs.([]interface{})
+	if !oursIsList || !theirsIsList || len(oursList) < len(baseList) || len(theirsList) < len(baseList) {
+		return nil, false
+	}
+	if !reflect.DeepEqual(oursList[:len(baseList)], baseList) || !reflect.DeepEqual(theirsList[:len(baseList)], baseList) {
+		return nil, false
+	}
+	merged := append(append([]interface{}{}, oursList...), theirsList[len(baseList):]...)
+	return merged, true
+}
+
+// mergeAppendOnly merges change logs that both sides only appended to, such
+// as those in .history: the entries added on the second side follow those of
+// the first. Entries are compared as whole YAML documents, so an entry is
+// never duplicated, even if a side doesn't extend base.
+func mergeAppendOnly(base, first, second string) string {
+	known := make(map[string]bool)
+	for _, document := range append(yamlDocuments(base), yamlDocuments(first)...) {
+		known[document] = true
+	}
+
+	merged := yamlDocuments(first)
+	for _, document := range yamlDocuments(second) {
+		if !known[document] {
+			known[document] = true
+			merged = append(merged, document)
+		}
+	}
+
+	var sb strings.Builder
+	for _, document := range merged {
+		sb.WriteString("---\n" + document + "\n")
+	}
+	return sb.String()
+}
+
+// yamlDocuments splits a stream of YAML documents separated by "---" lines,
+// dropping empty documents
+func yamlDocuments(content string) []string {
+	var documents []string
+	var lines []string
+	flush := func() {
+		if document := strings.TrimSpace(strings.Join(lines, "\n")); document != "" {
+			documents = append(documents, document)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.TrimRight(line, " ") == "---" {
+			flush()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return documents
+}
+
+// isChangeLog reports whether a file being merged is the change log of a
+// project rather than a project file. path is the file's path in the
+// repository (%P), if git passed it; otherwise every YAML document of the
+// contents must be a history entry.
+func isChangeLog(path string, contents []string) bool {
+	if path != "" {
+		path = filepath.ToSlash(path)
+		return strings.HasPrefix(path, historyDir+"/") || strings.Contains(path, "/"+historyDir+"/")
+	}
+
+	found := false
+	for _, content := range contents {
+		for _, document := range yamlDocuments(content) {
+			var fields map[string]interface{}
+			if err := yaml.Unmarshal([]byte(document), &fields); err != nil {
+				return false
+			}
+			for _, name := range []string{"time", "key", "old", "new"} {
+				if _, ok := fields[name]; !ok {
+					return false
+				}
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// mergeRecallFiles merges the contents of the ancestor, ours and theirs of a
+// file of a store, whose path in the repository is path (possibly unknown).
+// It reports whether conflicts are left in the result.
+func mergeRecallFiles(base, ours, theirs, path string) (string, bool, error) {
+	// Change logs are streams of YAML documents, combine their entries instead
+	if isChangeLog(path, []string{base, ours, theirs}) {
+		return mergeAppendOnly(base, ours, theirs), false, nil
+	}
+
+	var parsed [3]ProjectData
+	for i, content := range []string{base, ours, theirs} {
+		parsed[i] = make(ProjectData)
+		if err := yaml.Unmarshal([]byte(content), &parsed[i]); err != nil {
+			return "", false, fmt.Errorf("could not parse %s as YAML: %v", []string{"ancestor", "ours", "theirs"}[i], err)
+		}
+	}
+
+	unresolved := false
+	merged, conflicts := mergeProjectData(parsed[0], parsed[1], parsed[2], func(conflict mergeConflict) interface{} {
+		oursText, oursIsText := conflict.Ours.(string)
+		theirsText, theirsIsText := conflict.Theirs.(string)
+		if oursIsText && theirsIsText {
+			return "<<<<<<< ours\n" + oursText + "\n=======\n" + theirsText + "\n>>>>>>> theirs"
+		}
+		// Structural conflicts (e.g. changed on one side, removed on the other) keep ours
+		unresolved = true
+		return conflict.Ours
+	})
+	for _, conflict := range conflicts {
+		fmt.Fprintf(os.Stderr, "[WARN] Conflict in %s\n", conflict)
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), len(conflicts) > 0 || unresolved, nil
+}
+
+// mergeDriverCommand implements a git merge driver for recall files:
+//
+//	recall merge-driver %O %A %B %P
+//
+// It merges the parsed key trees of the ancestor (%O), ours (%A) and theirs
+// (%B) and writes the result to %A. Only a field changed differently on both
+// sides is a conflict: it is written with conflict markers inside the field's
+// text and the driver exits with status 1, as git expects. The path of the
+// file (%P) tells change logs in .history apart, whose entries are combined.
+func mergeDriverCommand(args []string) {
+	if len(args) == 1 && args[0] == "--install" {
+		installMergeDriver()
+		return
+	}
+	if len(args) != 3 && len(args) != 4 {
+		fmt.Println("[ERROR] Usage: recall merge-driver %O %A %B %P")
+		fmt.Println("       recall merge-driver --install")
+		os.Exit(2)
+	}
+
+	var contents [3]string
+	for i, file := range args[:3] {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+			os.Exit(2)
+		}
+		contents[i] = string(data)
+	}
+	path := ""
+	if len(args) == 4 {
+		path = args[3]
+	}
+
+	merged, conflicted, err := mergeRecallFiles(contents[0], contents[1], contents[2], path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		os.Exit(2)
+	}
+	if err := ioutil.WriteFile(args[1], []byte(merged), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		os.Exit(2)
+	}
+	if conflicted {
+		os.Exit(1)
+	}
+}
+
+// installMergeDriver registers the merge driver in the current repository and
+// assigns it to the YAML files of ./.recall
+func installMergeDriver() {
+	if !insideWorkTree(".") {
+		fmt.Println("[ERROR] Not inside a git work tree")
+		return
+	}
+	if _, err := runGit(".", "config", "merge.recall.name", "recall key tree merge"); err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	if _, err := runGit(".", "config", "merge.recall.driver", "recall merge-driver %O %A %B %P"); err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	fmt.Println("[INFO] Registered merge driver 'recall' in .git/config")
+
+	const attribute = ".recall/**/*.yaml merge=recall"
+	existing, _ := ioutil.ReadFile(".gitattributes")
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == attribute {
+			return
+		}
+	}
+	content := string(existing)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if err := ioutil.WriteFile(".gitattributes", []byte(content+attribute+"\n"), 0644); err != nil {
+		fmt.Printf("[ERROR] Could not update .gitattributes: %v\n", err)
+		return
+	}
+	fmt.Println("[INFO] Added '" + attribute + "' to .gitattributes")
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func parseTestYAML(t *testing.T, content string) ProjectData {
+	t.Helper()
+	projectData := make(ProjectData)
+	if err := yaml.Unmarshal([]byte(content), &projectData); err != nil {
+		t.Fatal(err)
+	}
+	return projectData
+}
+
+func keepOurs(conflict mergeConflict) interface{} { return conflict.Ours }
+
+func TestMergeProjectData(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicts          []string
+	}{
+		{
+			name:   "different keys",
+			base:   "db:\n  infoShort: a\napi:\n  infoShort: x\n",
+			ours:   "db:\n  infoShort: b\napi:\n  infoShort: x\n",
+			theirs: "db:\n  infoShort: a\napi:\n  infoShort: y\n",
+			want:   "db:\n  infoShort: b\napi:\n  infoShort: y\n",
+		},
+		{
+			name:   "different fields of a key",
+			base:   "db:\n  infoShort: a\n  example: x\n",
+			ours:   "db:\n  infoShort: b\n  example: x\n",
+			theirs: "db:\n  infoShort: a\n  example: y\n",
+			want:   "db:\n  infoShort: b\n  example: y\n",
+		},
+		{
+			name:   "sub-keys added on both sides",
+			base:   "db:\n  infoShort: a\n",
+			ours:   "db:\n  infoShort: a\n  keys:\n    pool:\n      infoShort: p\n",
+			theirs: "db:\n  infoShort: a\n  keys:\n    conn:\n      infoShort: c\n",
+			want:   "db:\n  infoShort: a\n  keys:\n    pool:\n      infoShort: p\n    conn:\n      infoShort: c\n",
+		},
+		{
+			name:   "key removed on one side",
+			base:   "db:\n  infoShort: a\napi:\n  infoShort: x\n",
+			ours:   "db:\n  infoShort: a\n",
+			theirs: "db:\n  infoShort: b\napi:\n  infoShort: x\n",
+			want:   "db:\n  infoShort: b\n",
+		},
+		{
+			name:   "journal entries added on both sides",
+			base:   "db:\n  journal:\n  - text: one\n",
+			ours:   "db:\n  journal:\n  - text: one\n  - text: two\n",
+			theirs: "db:\n  journal:\n  - text: one\n  - text: three\n",
+			want:   "db:\n  journal:\n  - text: one\n  - text: two\n  - text: three\n",
+		},
+		{
+			name:      "same field changed on both sides",
+			base:      "db:\n  infoShort: a\n",
+			ours:      "db:\n  infoShort: b\n",
+			theirs:    "db:\n  infoShort: c\n",
+			want:      "db:\n  infoShort: b\n",
+			conflicts: []string{"db (infoShort)"},
+		},
+		{
+			name:      "changed on one side, removed on the other",
+			base:      "db:\n  keys:\n    pool:\n      infoShort: a\n",
+			ours:      "db:\n  keys: {}\n",
+			theirs:    "db:\n  keys:\n    pool:\n      infoShort: b\n",
+			want:      "db:\n  keys: {}\n",
+			conflicts: []string{"db → pool"},
+		},
+	}
+	for _, tt := range tests {
+		merged, conflicts := mergeProjectData(parseTestYAML(t, tt.base), parseTestYAML(t, tt.ours), parseTestYAML(t, tt.theirs), keepOurs)
+		if want := normalizeValue(parseTestYAML(t, tt.want)); !reflect.DeepEqual(normalizeValue(merged), want) {
+			t.Errorf("%s: merged %v, want %v", tt.name, normalizeValue(merged), want)
+		}
+		var labels []string
+		for _, conflict := range conflicts {
+			labels = append(labels, conflict.String())
+		}
+		if !reflect.DeepEqual(labels, tt.conflicts) {
+			t.Errorf("%s: conflicts %v, want %v", tt.name, labels, tt.conflicts)
+		}
+	}
+}
+
+func TestMergeAppendOnly(t *testing.T) {
+	entry := func(text string) string {
+		return "---\ntime: \"1\"\nkey: [db]\nold: {}\nnew:\n  infoShort: " + text + "\n"
+	}
+	tests := []struct {
+		name                string
+		base, first, second string
+		want                string
+	}{
+		{"both appended", entry("a"), entry("a") + entry("b"), entry("a") + entry("c"), entry("a") + entry("b") + entry("c")},
+		{"new file on both sides", "", entry("b"), entry("c"), entry("b") + entry("c")},
+		{"second doesn't extend base", entry("a"), entry("a") + entry("b"), entry("c"), entry("a") + entry("b") + entry("c")},
+		{"same entry on both sides", entry("a"), entry("a") + entry("b"), entry("a") + entry("b"), entry("a") + entry("b")},
+		{"only first appended", entry("a"), entry("a") + entry("b"), entry("a"), entry("a") + entry("b")},
+	}
+	for _, tt := range tests {
+		if got := mergeAppendOnly(tt.base, tt.first, tt.second); got != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeRecallFilesDocumentMarker(t *testing.T) {
+	// Project files may start with the YAML document marker
+	base := "---\ndb:\n  infoShort: a\napi:\n  infoShort: old\n"
+	ours := "---\ndb:\n  infoShort: b\napi:\n  infoShort: old\n"
+	theirs := "---\ndb:\n  infoShort: a\napi:\n  infoShort: new\n"
+	for _, path := range []string{".recall/app.yaml", ""} {
+		merged, conflicted, err := mergeRecallFiles(base, ours, theirs, path)
+		if err != nil || conflicted {
+			t.Fatalf("path %q: conflicted %v, err %v", path, conflicted, err)
+		}
+		data := parseTestYAML(t, merged)
+		if getKeyData(data, "db").InfoShort != "b" || getKeyData(data, "api").InfoShort != "new" {
+			t.Errorf("path %q: merged %q", path, merged)
+		}
+	}
+
+	// Changing the same field is a conflict, not a concatenation of documents
+	merged, conflicted, err := mergeRecallFiles(base, ours, strings.Replace(base, "a", "c", 1), "")
+	if err != nil || !conflicted {
+		t.Fatalf("conflicted %v, err %v", conflicted, err)
+	}
+	if got := getKeyData(parseTestYAML(t, merged), "db").InfoShort; got != "<<<<<<< ours\nb\n=======\nc\n>>>>>>> theirs" {
+		t.Errorf("conflicting field = %q", got)
+	}
+}
+
+func TestIsChangeLog(t *testing.T) {
+	entry := "---\ntime: \"1\"\nuser: me\nkey: [db]\nold: {}\nnew: {}\n"
+	tests := []struct {
+		path     string
+		contents []string
+		want     bool
+	}{
+		{".recall/.history/app.yaml", []string{"db: {}\n"}, true},
+		{".history/team/app.yaml", nil, true},
+		{".recall/app.yaml", []string{entry}, false},
+		{"", []string{"", entry, entry + entry}, true},
+		{"", []string{"---\ndb:\n  infoShort: a\n"}, false},
+		{"", []string{entry, "---\ndb: {}\n"}, false},
+		{"", []string{"", ""}, false},
+	}
+	for _, tt := range tests {
+		if got := isChangeLog(tt.path, tt.contents); got != tt.want {
+			t.Errorf("isChangeLog(%q, %q) = %v, want %v", tt.path, tt.contents, got, tt.want)
+		}
+	}
+}
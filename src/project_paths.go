@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localStoreDir is the per-project store, relative to the working directory
+const localStoreDir = "./.recall"
+
+// subcommands are the commands given in place of a project, e.g. recall sync
+var subcommands = []string{"add", "completion", "export", "import", "log", "merge-driver", "run", "sync"}
+
+// reservedProjectNames lists names that belong to recall itself: its own
+// files inside a store and its subcommands
+var reservedProjectNames = reservedNames()
+
+func reservedNames() map[string]bool {
+	names := map[string]bool{
+		"settings":   true, // ~/.recall/settings.yaml
+		"__complete": true, // called by the completion scripts
+	}
+	for _, command := range subcommands {
+		names[command] = true
+	}
+	return names
+}
+
+// globalStoreDir returns the store in the user's home directory (~/.recall)
+func globalStoreDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".recall")
+}
+
+// validateProjectName checks a project name given on the command line.
+// Names may be namespaced with '/' (e.g. "team/backend"), but every segment
+// must be a plain file name so the project always stays inside its store.
+func validateProjectName(project string) error {
+	if project == "" {
+		return fmt.Errorf("project name must not be empty")
+	}
+	if strings.HasPrefix(project, "-") {
+		return fmt.Errorf("unknown option '%s'", project)
+	}
+	if strings.ContainsAny(project, "\\\x00") {
+		return fmt.Errorf("project name '%s' contains invalid characters", project)
+	}
+	if strings.HasPrefix(project, "/") || filepath.IsAbs(project) {
+		return fmt.Errorf("project name '%s' must not be an absolute path", project)
+	}
+
+	for _, segment := range strings.Split(project, "/") {
+		if segment == "" {
+			return fmt.Errorf("project name '%s' contains an empty namespace segment", project)
+		}
+		if strings.HasPrefix(segment, ".") {
+			return fmt.Errorf("project name '%s' must not contain segments starting with '.'", project)
+		}
+	}
+
+	if reservedProjectNames[strings.ToLower(project)] {
+		return fmt.Errorf("project name '%s' is reserved by recall", project)
+	}
+	return nil
+}
+
+// projectBasePath builds the location of project inside storeDir, mapping
+// namespaces to subdirectories: "team/backend" becomes <storeDir>/team/backend.
+// Storage backends add their own extension.
+func projectBasePath(storeDir, project string) (string, error) {
+	if err := validateProjectName(project); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(storeDir, filepath.FromSlash(project))
+
+	// Defense in depth: the joined path must never leave the store
+	rel, err := filepath.Rel(storeDir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("project name '%s' resolves outside of %s", project, storeDir)
+	}
+	return path, nil
+}
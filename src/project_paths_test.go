@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateProjectName(t *testing.T) {
+	valid := []string{"app", "team/backend", "my-app_2", "a.b"}
+	for _, name := range valid {
+		if err := validateProjectName(name); err != nil {
+			t.Errorf("validateProjectName(%q): %v", name, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                 "must not be empty",
+		"--lst":            "unknown option",
+		"../../etc/passwd": "starting with '.'",
+		"team/../x":        "starting with '.'",
+		".history":         "starting with '.'",
+		"/etc/passwd":      "absolute path",
+		"team//backend":    "empty namespace segment",
+		"team/":            "empty namespace segment",
+		`team\backend`:     "invalid characters",
+		"settings":         "reserved",
+		"Settings":         "reserved",
+		"sync":             "reserved",
+		"__complete":       "reserved",
+	}
+	for name, want := range invalid {
+		if err := validateProjectName(name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("validateProjectName(%q) = %v, want an error containing %q", name, err, want)
+		}
+	}
+}
+
+func TestProjectBasePath(t *testing.T) {
+	store := t.TempDir()
+	path, err := projectBasePath(store, "team/backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(store, "team", "backend"); path != want {
+		t.Errorf("projectBasePath = %s, want %s", path, want)
+	}
+	if _, err := projectBasePath(store, "../outside"); err == nil {
+		t.Error("a project outside of the store is accepted")
+	}
+}
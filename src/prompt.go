@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// stdinReader is shared by all prompts, so buffered input isn't lost
+// between them
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// prompt asks for a line of input. It returns false if there is no input.
+func prompt(question string) (string, bool) {
+	fmt.Printf("%s ", question)
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return "", false
+	}
+	return strings.TrimRight(answer, "\r\n"), true
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no
+func confirm(question string) bool {
+	answer, _ := prompt(question + " [y/N]")
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches placeholders like {{host}} in examples
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// placeholderNames returns the names of the placeholders in text, in the
+// order they first appear
+func placeholderNames(text string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// expandPlaceholders replaces the placeholders that have a value in values,
+// leaving the others untouched
+func expandPlaceholders(text string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// shellSafePattern matches values that need no quoting in a shell
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes value as a single word for sh, leaving values without
+// special characters as they are
+func shellQuote(value string) string {
+	if shellSafePattern.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// runCommand handles `recall run <project> <key>... [--var name=value]... [--unquoted] [--yes]`:
+// it shows the example of a key and runs it in a shell after confirmation.
+// Values are inserted shell-quoted, or as shell code with --unquoted.
+func runCommand(settings *Settings, args []string) {
+	args, yes := extractFlag(args, "--yes")
+	args, unquoted := extractFlag(args, "--unquoted")
+	args, vars, err := extractOptionValues(args, "--var")
+	if err != nil || len(args) < 2 {
+		fmt.Println("[ERROR] Usage: recall run <project> <key>... [--var name=value]... [--unquoted] [--yes]")
+		fmt.Println("       {{name}} placeholders are replaced by their values shell-quoted, or as shell code with --unquoted")
+		os.Exit(1)
+	}
+	given := make(map[string]string)
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			fmt.Printf("[ERROR] --var expects name=value, got '%s'\n", v)
+			os.Exit(1)
+		}
+		given[name] = value
+	}
+	project, keyPath := args[0], args[1:]
+
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		os.Exit(1)
+	}
+	projectData := loadProjectData(projectFile)
+	example := parseMarkdownFence(getKeyData(projectData, keyPathString(keyPath)).Example)
+	if example == "" {
+		fmt.Printf("[ERROR] Key '%s' has no example to run\n", strings.Join(keyPath, " → "))
+		os.Exit(1)
+	}
+
+	// Template variables are filled in like in showKey, --var overrides them
+	// and the remaining placeholders are asked for
+	values := templateVariables(projectFile, projectData)
+	for name, value := range given {
+		values[name] = value
+	}
+	for _, name := range placeholderNames(example) {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		value, ok := prompt(fmt.Sprintf("Value for {{%s}}:", name))
+		if !ok {
+			fmt.Println("[INFO] Aborted, nothing was run")
+			os.Exit(1)
+		}
+		values[name] = value
+	}
+	if !unquoted {
+		for name, value := range values {
+			values[name] = shellQuote(value)
+		}
+	}
+	script := expandPlaceholders(example, values)
+
+	fmt.Printf("\033[1;32m#############################\033[0m\n")
+	fmt.Printf("\033[1;32mCommand:\033[0m\n%s\n", script)
+	fmt.Printf("\033[1;32m#############################\033[0m\n")
+	if !yes && !confirm("Run this command?") {
+		fmt.Println("[INFO] Aborted, nothing was run")
+		return
+	}
+
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Printf("[ERROR] Could not run the command: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholderNames(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"make build", nil},
+		{"scp {{file}} {{ env.USER }}@{{host}}:{{file}}", []string{"file", "env.USER", "host"}},
+		{"{{project-root}}/{{_x1}}", []string{"project-root", "_x1"}},
+		{"{{1st}} {{}} {{a b}} {host} {{{host}}}", []string{"host"}},
+	}
+	for _, tt := range tests {
+		if got := placeholderNames(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("placeholderNames(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	values := map[string]string{"host": "example.com", "env.USER": "me", "empty": "", "nested": "{{host}}"}
+	tests := map[string]string{
+		"ssh {{env.USER}}@{{ host }}": "ssh me@example.com",
+		"echo {{missing}} {{host}}":   "echo {{missing}} example.com",
+		"x{{empty}}y":                 "xy",
+		"{{nested}}":                  "{{host}}",
+		"echo '{{host}}; {{ host}}'":  "echo 'example.com; example.com'",
+	}
+	for text, want := range tests {
+		if got := expandPlaceholders(text, values); got != want {
+			t.Errorf("expandPlaceholders(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"staging.example.com": "staging.example.com",
+		"user@host:/srv/app":  "user@host:/srv/app",
+		"":                    "''",
+		"two words":           "'two words'",
+		"x; rm -rf ~":         "'x; rm -rf ~'",
+		"$(id) `id` $HOME":    "'$(id) `id` $HOME'",
+		"it's":                `'it'\''s'`,
+		"line\nbreak":         "'line\nbreak'",
+	}
+	for value, want := range tests {
+		if got := shellQuote(value); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", value, got, want)
+		}
+		// sh passes the quoted value on as one unchanged argument
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(value)).Output()
+		if err != nil || string(out) != value {
+			t.Errorf("sh printed %q for %s, %v", out, shellQuote(value), err)
+		}
+	}
+}
@@ -3,32 +3,55 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"gopkg.in/yaml.v2"
 )
 
 // Settings holds configuration for the recall application
 type Settings struct {
-	Editor string // Preferred editor (nano, vim, etc.)
+	Editor          string          // Preferred editor command line if $VISUAL and $EDITOR are unset
+	Storage         StorageSettings // Storage backend per store
+	GitAutoCommit   bool            `yaml:"gitAutoCommit"` // Commit every save when the store is in a git work tree
+	SyncRemote      string          `yaml:"syncRemote,omitempty"` // Git remote of ~/.recall for `recall sync`
+	SyncBranch      string          `yaml:"syncBranch,omitempty"` // Branch to sync, defaults to main
+	EditTemplate    string          `yaml:"editTemplate,omitempty"` // Format of the --edit file: text, yaml or markdown
+	ExampleLanguage string          `yaml:"exampleLanguage,omitempty"` // Fence language of examples in the markdown template
+}
+
+// StorageSettings selects the storage backend ("yaml", "markdown" or "sqlite") of each store
+type StorageSettings struct {
+	Local  string `yaml:"local,omitempty"`  // ./.recall
+	Global string `yaml:"global,omitempty"` // ~/.recall
 }
 
 // Default settings
 func defaultSettings() *Settings {
 	return &Settings{
 		Editor: "nano",
+		EditTemplate: "text",
+		Storage: StorageSettings{
+			Local:  "yaml",
+			Global: "yaml",
+		},
 	}
 }
 
+// settingsFilePath returns ~/.recall/settings.yaml
+func settingsFilePath() string {
+	return filepath.Join(globalStoreDir(), "settings.yaml")
+}
+
 func loadSettings() *Settings {
 	// Load settings from ~/.recall/settings.yaml
-	homeDir, _ := os.UserHomeDir()
-	settingsFile := homeDir + "/.recall/settings.yaml"
+	settingsFile := settingsFilePath()
 	// Try loading settings from the file
 	// If file doesn't exist, return default settings
 	if _, err := os.Stat(settingsFile); os.IsNotExist(err) {
 		// Warn user to run `recall --init-global` to create settings
-		fmt.Println("Settings file not found. Please run `recall --init-global` to create default settings.")
-		fmt.Println("Using default settings.")
-		fmt.Println("")
+		// (on stderr, so output like `recall export` can be redirected cleanly)
+		fmt.Fprintln(os.Stderr, "Settings file not found. Please run `recall --init-global` to create default settings.")
+		fmt.Fprintln(os.Stderr, "Using default settings.")
+		fmt.Fprintln(os.Stderr, "")
 
 		// Return default settings
 		return defaultSettings()
@@ -36,14 +59,14 @@ func loadSettings() *Settings {
 	// Load settings from the file
 	file, err := os.Open(settingsFile)
 	if err != nil {
-		fmt.Println("Error loading settings:", err)
+		fmt.Fprintln(os.Stderr, "Error loading settings:", err)
 		return defaultSettings()
 	}
 	defer file.Close()
 
 	var settings Settings
 	if err := yaml.NewDecoder(file).Decode(&settings); err != nil {
-		fmt.Println("Error decoding settings:", err)
+		fmt.Fprintln(os.Stderr, "Error decoding settings:", err)
 		return defaultSettings()
 	}
 
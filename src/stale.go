@@ -0,0 +1,325 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// keySource is the source code a key documents, stored in its "source" field:
+//
+//	source:
+//	  file: src/data_operations.go
+//	  symbol: getKeyData
+//	  hash: <sha256 of the file when the key was last edited>
+//
+// Relative file names are resolved against the directory containing the
+// store, i.e. the project root for ./.recall.
+type keySource struct {
+	File   string
+	Symbol string
+	Hash   string
+}
+
+// readKeySource returns the source referenced by a key node, if any
+func readKeySource(node map[string]interface{}) (keySource, bool) {
+	fields, ok := toStringMap(node["source"])
+	if !ok {
+		return keySource{}, false
+	}
+	var source keySource
+	source.File, _ = fields["file"].(string)
+	source.Symbol, _ = fields["symbol"].(string)
+	source.Hash, _ = fields["hash"].(string)
+	return source, source.File != ""
+}
+
+func writeKeySource(node map[string]interface{}, source keySource) {
+	fields := map[string]interface{}{"file": source.File}
+	if source.Symbol != "" {
+		fields["symbol"] = source.Symbol
+	}
+	if source.Hash != "" {
+		fields["hash"] = source.Hash
+	}
+	node["source"] = fields
+}
+
+// resolveSourcePath returns the location of a referenced file on disk
+func resolveSourcePath(location projectLocation, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(filepath.Dir(location.Store), filepath.FromSlash(file))
+}
+
+// sourceReference converts a file name given on the command line into the
+// form stored in a key: relative to the project root for the local store,
+// absolute for the global store
+func sourceReference(location projectLocation, file string) (string, error) {
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	if location.Store != localStoreDir {
+		return absFile, nil
+	}
+
+	absRoot, err := filepath.Abs(filepath.Dir(location.Store))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absFile)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return absFile, nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// hashSourceFile returns the hex encoded SHA-256 of a file
+func hashSourceFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// refreshSourceHash records the current hash of the file referenced by the
+// key at path, marking the entry as up to date
+func refreshSourceHash(location projectLocation, projectData ProjectData, path string) {
+	node, ok := getKeyNode(projectData, path)
+	if !ok {
+		return
+	}
+	source, ok := readKeySource(node)
+	if !ok {
+		return
+	}
+	hash, err := hashSourceFile(resolveSourcePath(location, source.File))
+	if err != nil {
+		return
+	}
+	source.Hash = hash
+	writeKeySource(ensureKeyNode(projectData, path), source)
+}
+
+// checkKeySource returns why the source of a key is stale, or "" if it's up to date
+func checkKeySource(location projectLocation, source keySource) string {
+	path := resolveSourcePath(location, source.File)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("file %s no longer exists", source.File)
+	}
+
+	if source.Symbol != "" && !symbolDeclared(path, data, source.Symbol) {
+		return fmt.Sprintf("symbol %s no longer exists in %s", source.Symbol, source.File)
+	}
+
+	sum := sha256.Sum256(data)
+	if source.Hash != "" && source.Hash != hex.EncodeToString(sum[:]) {
+		return fmt.Sprintf("%s changed since the entry was last edited", source.File)
+	}
+	return ""
+}
+
+// symbolDeclared reports whether the source file at path declares symbol.
+// Go files are parsed, so mentions in comments or calls don't count, and
+// methods may be given as Type.Method. Other languages are searched for the
+// symbol as a word.
+func symbolDeclared(path string, data []byte, symbol string) bool {
+	if filepath.Ext(path) == ".go" {
+		if file, err := parser.ParseFile(token.NewFileSet(), path, data, parser.SkipObjectResolution); err == nil {
+			return goSymbolDeclared(file, symbol)
+		}
+	}
+
+	// Methods may be given as Type.Method, the method name is enough to find it
+	symbol = symbol[strings.LastIndex(symbol, ".")+1:]
+	pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(symbol) + `\b`)
+	return pattern.Match(data)
+}
+
+// goSymbolDeclared reports whether file declares a top-level function, method,
+// type, variable or constant named symbol, or the method Type.Method
+func goSymbolDeclared(file *ast.File, symbol string) bool {
+	receiver, name := "", symbol
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		receiver, name = symbol[:i], symbol[i+1:]
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			// A plain name also finds methods, so references made by hand keep working
+			if decl.Name.Name == name && (receiver == "" || goReceiverType(decl) == receiver) {
+				return true
+			}
+		case *ast.GenDecl:
+			if receiver != "" {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == name {
+						return true
+					}
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if ident.Name == name {
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// goReceiverType returns the receiver type name of a method, without pointer
+// and type parameters, or "" for a function
+func goReceiverType(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	expr := decl.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// sourceCommand handles "recall --source <project> <key>... --file <file> [--symbol <name>]"
+// and "recall --source <project> <key>... --remove"
+func sourceCommand(settings *Settings, args []string) {
+	args, remove := extractFlag(args, "--remove")
+	args, file, err := extractOption(args, "--file")
+	symbol := ""
+	if err == nil {
+		args, symbol, err = extractOption(args, "--symbol")
+	}
+	if err != nil || len(args) < 2 || (file == "" && !remove) || (file != "" && remove) {
+		fmt.Println("[ERROR] Usage: recall --source <project> <key>... --file <file> [--symbol <name>]")
+		fmt.Println("       recall --source <project> <key>... --remove")
+		os.Exit(1)
+	}
+
+	project, keyPath := args[0], args[1:]
+	projectFile, err := findProjectFile(settings, project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	projectData := loadProjectData(projectFile)
+	path := keyPathString(keyPath)
+	label := strings.Join(keyPath, " → ")
+
+	if _, exists := getKeyNode(projectData, path); !exists {
+		fmt.Printf("[ERROR] Key '%s' not found. Use --edit to create it.\n", label)
+		return
+	}
+
+	if remove {
+		delete(ensureKeyNode(projectData, path), "source")
+	} else {
+		reference, err := sourceReference(projectFile, file)
+		if err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+		source := keySource{File: reference, Symbol: symbol}
+		if problem := checkKeySource(projectFile, source); problem != "" {
+			fmt.Printf("[ERROR] Cannot reference source: %s\n", problem)
+			return
+		}
+		writeKeySource(ensureKeyNode(projectData, path), source)
+		refreshSourceHash(projectFile, projectData, path)
+	}
+
+	if err := saveProjectData(projectFile, projectData); err != nil {
+		fmt.Printf("[ERROR] Error saving %s: %v\n", projectFile.Path, err)
+		return
+	}
+	if remove {
+		fmt.Printf("[INFO] Removed source reference of '%s'\n", label)
+	} else {
+		fmt.Printf("[INFO] Key '%s' now references %s\n", label, file)
+	}
+}
+
+// staleCommand reports every key whose referenced source file or symbol no
+// longer exists or whose file changed since the key was last edited. It
+// checks a single project, or every project of every store. It returns
+// whether stale entries were found.
+func staleCommand(settings *Settings, project string) bool {
+	var locations []projectLocation
+	if project != "" {
+		projectFile, err := findProjectFile(settings, project)
+		if err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return false
+		}
+		locations = append(locations, projectFile)
+	} else {
+		for _, storeDir := range storeDirs() {
+			projects, err := listProjects(settings, storeDir, "")
+			if err != nil {
+				fmt.Printf("[ERROR] Could not list %s: %v\n", storeDir, err)
+				continue
+			}
+			for _, name := range projects {
+				if location, err := locateProject(settings, storeDir, name); err == nil {
+					locations = append(locations, location)
+				}
+			}
+		}
+	}
+
+	stale, checked := 0, 0
+	for _, location := range locations {
+		walkNodes(loadProjectData(location), func(keyPath []string, node map[string]interface{}) {
+			source, ok := readKeySource(node)
+			if !ok {
+				return
+			}
+			checked++
+			problem := checkKeySource(location, source)
+			if problem == "" {
+				return
+			}
+			stale++
+			label := strings.Join(keyPath, " → ")
+			if len(keyPath) == 0 {
+				label = "(general info)"
+			}
+			fmt.Printf("\033[1;33m%s\033[0m %s\n  %s\n", location.Project, label, problem)
+		})
+	}
+
+	if stale == 0 {
+		fmt.Printf("[INFO] All %d entries with a source reference are up to date\n", checked)
+		return false
+	}
+	fmt.Printf("[INFO] %d of %d entries with a source reference are stale. Use --edit to review them.\n", stale, checked)
+	return true
+}
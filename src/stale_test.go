@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const staleTestSource = `package shop
+
+// Price used to be computed by oldPrice.
+func Price() int { return helper() }
+
+func helper() int { return 0 }
+
+type Cart[T any] struct{}
+
+func (c *Cart[T]) Total() int { return Price() }
+
+var (
+	taxRate, discount = 1, 2
+)
+
+const currency = "EUR"
+`
+
+func TestSymbolDeclaredInGoFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.go")
+	writeTestFile(t, path, staleTestSource)
+	data := []byte(staleTestSource)
+
+	tests := []struct {
+		symbol string
+		want   bool
+	}{
+		{"Price", true},
+		{"helper", true},
+		{"Cart", true},
+		{"Cart.Total", true},
+		{"discount", true},
+		{"currency", true},
+		{"oldPrice", false},    // only mentioned in a comment
+		{"Total", true},        // the method name alone
+		{"Price.Total", false}, // wrong receiver
+		{"shop", false},
+	}
+	for _, tt := range tests {
+		if got := symbolDeclared(path, data, tt.symbol); got != tt.want {
+			t.Errorf("symbolDeclared(%s) = %v, want %v", tt.symbol, got, tt.want)
+		}
+	}
+}
+
+func TestSymbolDeclaredInOtherFiles(t *testing.T) {
+	data := []byte("def price():\n    return old_price()\n")
+	if !symbolDeclared("shop.py", data, "price") || !symbolDeclared("shop.py", data, "Shop.old_price") {
+		t.Error("symbols of other languages are found as words")
+	}
+	if symbolDeclared("shop.py", data, "pri") {
+		t.Error("a part of a word is not a symbol")
+	}
+}
+
+func TestCheckKeySourceReportsDeletedGoFunction(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "shop.go"), staleTestSource)
+	location := projectLocation{Store: filepath.Join(dir, ".recall")}
+	if problem := checkKeySource(location, keySource{File: "shop.go", Symbol: "oldPrice"}); problem == "" {
+		t.Error("a function only mentioned in a comment is reported as stale")
+	}
+	if problem := checkKeySource(location, keySource{File: "shop.go", Symbol: "Cart.Total"}); problem != "" {
+		t.Errorf("existing method reported as stale: %s", problem)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// storageBackend reads and writes the projects of a store. The YAML file
+// backend is the default; others are selected per store in settings.yaml.
+type storageBackend interface {
+	// projectPath returns the file or directory holding project inside storeDir
+	projectPath(storeDir, project string) (string, error)
+	// load returns the project data, or empty ProjectData if it doesn't exist yet
+	load(location projectLocation) (ProjectData, error)
+	save(location projectLocation, projectData ProjectData) error
+	// remove deletes the project from the store
+	remove(location projectLocation) error
+	// listProjects returns the sorted names of all projects in storeDir below namespace
+	listProjects(storeDir, namespace string) ([]string, error)
+}
+
+// storageBackends maps the names usable in settings.yaml to their implementation
+var storageBackends = map[string]storageBackend{
+	"yaml":     yamlBackend{},
+	"markdown": markdownBackend{},
+	"sqlite":   sqliteBackend{},
+}
+
+// projectLocation identifies a project inside a store
+type projectLocation struct {
+	Store   string // store directory, e.g. ./.recall
+	Project string // project name, e.g. team/backend
+	Path    string // file or directory holding the project
+	Backend storageBackend
+
+	AutoCommit bool // commit every save to git, see settings.GitAutoCommit
+}
+
+// backendName returns the name of backend as used in settings.yaml
+func backendName(backend storageBackend) string {
+	for name, b := range storageBackends {
+		if b == backend {
+			return name
+		}
+	}
+	return ""
+}
+
+// storeBackend returns the backend configured for storeDir
+func storeBackend(settings *Settings, storeDir string) (storageBackend, error) {
+	name := settings.Storage.Global
+	if storeDir == localStoreDir {
+		name = settings.Storage.Local
+	}
+	if name == "" {
+		name = "yaml"
+	}
+
+	backend, ok := storageBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend '%s' for %s", name, storeDir)
+	}
+	return backend, nil
+}
+
+// locateProject resolves project inside a specific store
+func locateProject(settings *Settings, storeDir, project string) (projectLocation, error) {
+	backend, err := storeBackend(settings, storeDir)
+	if err != nil {
+		return projectLocation{}, err
+	}
+	path, err := backend.projectPath(storeDir, project)
+	if err != nil {
+		return projectLocation{}, err
+	}
+	return projectLocation{
+		Store:      storeDir,
+		Project:    project,
+		Path:       path,
+		Backend:    backend,
+		AutoCommit: settings.GitAutoCommit,
+	}, nil
+}
+
+func findProjectFile(settings *Settings, project string) (projectLocation, error) {
+	if err := validateProjectName(project); err != nil {
+		return projectLocation{}, err
+	}
+
+	// Check if local .recall directory exists
+	if _, err := os.Stat(localStoreDir); err == nil {
+		// Local .recall directory exists, prefer local storage
+		local, err := locateProject(settings, localStoreDir, project)
+		if err != nil || projectExists(local) {
+			return local, err
+		}
+
+		// Projects that only exist globally are still found from a project
+		// directory; new projects are created in the local store
+		global, err := locateProject(settings, globalStoreDir(), project)
+		if err == nil && projectExists(global) {
+			return global, nil
+		}
+		return local, nil
+	}
+
+	// Fall back to global storage
+	return locateProject(settings, globalStoreDir(), project)
+}
+
+// projectExists reports whether location holds any data
+func projectExists(location projectLocation) bool {
+	projectData, err := location.Backend.load(location)
+	return err == nil && len(projectData) > 0
+}
+
+func loadProjectData(location projectLocation) ProjectData {
+	projectData, err := location.Backend.load(location)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return make(ProjectData)
+	}
+	return projectData
+}
+
+func saveProjectData(location projectLocation, projectData ProjectData) error {
+	return storeProjectData(location, projectData, true)
+}
+
+// storeProjectData saves projectData, or removes the project if it is nil,
+// and records the change in the history, the undo journal (if journal is
+// set) and git (if enabled)
+func storeProjectData(location projectLocation, projectData ProjectData, journal bool) error {
+	// Keep the previous state to log what changed
+	previous, err := location.Backend.load(location)
+	if err != nil {
+		previous = make(ProjectData)
+	}
+
+	if projectData == nil {
+		err = location.Backend.remove(location)
+	} else {
+		err = location.Backend.save(location, projectData)
+	}
+	if err != nil {
+		return err
+	}
+
+	if journal {
+		if err := recordUndo(location, previous); err != nil {
+			fmt.Printf("[WARN] Could not record undo information: %v\n", err)
+		}
+	}
+
+	changes := projectChanges(previous, projectData)
+	if err := recordHistory(location, changes); err != nil {
+		fmt.Printf("[WARN] Could not record history: %v\n", err)
+	}
+	if location.AutoCommit {
+		if err := commitStoreChanges(location, changes); err != nil {
+			fmt.Printf("[WARN] Could not commit changes: %v\n", err)
+		}
+	}
+	return nil
+}
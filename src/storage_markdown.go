@@ -0,0 +1,398 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"gopkg.in/yaml.v2"
+)
+
+// markdownIndexFile holds the general project info inside a project directory
+const markdownIndexFile = "_index.md"
+
+// markdownExampleHeading separates infoLong from the example
+const markdownExampleHeading = "## Example"
+
+// markdownBackend stores each project as a directory tree of Markdown files:
+//
+//	<store>/<project>/_index.md        general project info
+//	<store>/<project>/<key>.md         a key
+//	<store>/<project>/<key>/<sub>.md   its sub-keys
+//
+// infoShort and any additional fields live in the YAML front matter,
+// infoLong is the body and the example is a fenced block under "## Example".
+type markdownBackend struct{}
+
+func (markdownBackend) projectPath(storeDir, project string) (string, error) {
+	return projectBasePath(storeDir, project)
+}
+
+func (markdownBackend) load(location projectLocation) (ProjectData, error) {
+	projectData := make(ProjectData)
+
+	indexFile := filepath.Join(location.Path, markdownIndexFile)
+	if _, err := os.Stat(indexFile); os.IsNotExist(err) {
+		// Project doesn't exist yet
+		return projectData, nil
+	}
+
+	info, err := readMarkdownNode(indexFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(info) > 0 {
+		projectData["info"] = info
+	}
+
+	keys, err := readMarkdownKeys(location.Path)
+	if err != nil {
+		return nil, err
+	}
+	for key, node := range keys {
+		projectData[key] = node
+	}
+	return projectData, nil
+}
+
+// isMarkdownProject reports whether dir holds a project of its own, e.g. the
+// nested project work/api inside the directory of project work
+func isMarkdownProject(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, markdownIndexFile))
+	return err == nil
+}
+
+// readMarkdownKeys reads every <key>.md in dir together with its <key>/ sub-directory
+func readMarkdownKeys(dir string) (map[string]interface{}, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{})
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == markdownIndexFile || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".md" {
+			continue
+		}
+		key := strings.TrimSuffix(name, ".md")
+
+		node, err := readMarkdownNode(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		subDir := filepath.Join(dir, key)
+		if info, err := os.Stat(subDir); err == nil && info.IsDir() && !isMarkdownProject(subDir) {
+			subKeys, err := readMarkdownKeys(subDir)
+			if err != nil {
+				return nil, err
+			}
+			if len(subKeys) > 0 {
+				node["keys"] = subKeys
+			}
+		}
+		keys[key] = node
+	}
+	return keys, nil
+}
+
+// readMarkdownNode parses a single Markdown file into a key node
+func readMarkdownNode(filename string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %v", err)
+	}
+	return parseMarkdownNode(string(data), filename)
+}
+
+// parseMarkdownNode parses the content of a Markdown key file
+func parseMarkdownNode(content, filename string) (map[string]interface{}, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	node := make(map[string]interface{})
+	if strings.HasPrefix(content, "---\n") {
+		end := strings.Index(content[4:], "\n---\n")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated front matter in %s", filename)
+		}
+		var frontMatter map[string]interface{}
+		if err := yaml.Unmarshal([]byte(content[4:4+end]), &frontMatter); err != nil {
+			return nil, fmt.Errorf("could not parse front matter in %s: %v", filename, err)
+		}
+		for k, v := range frontMatter {
+			node[k] = v
+		}
+		content = content[4+end+5:]
+	}
+
+	lines := strings.Split(content, "\n")
+	if idx := markdownExampleIndex(lines); idx >= 0 {
+		if example := parseMarkdownFence(strings.Join(lines[idx+1:], "\n")); example != "" {
+			node["example"] = example
+		}
+		lines = lines[:idx]
+	}
+	if infoLong := strings.TrimSpace(strings.Join(lines, "\n")); infoLong != "" {
+		node["infoLong"] = infoLong
+	}
+	return node, nil
+}
+
+// markdownExampleIndex returns the line of the last "## Example" heading that
+// is not inside a fenced code block, so examples and infoLong may contain the
+// heading in code. It returns -1 if there is none.
+func markdownExampleIndex(lines []string) int {
+	index, last := -1, -1
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == markdownExampleHeading {
+			last = i
+		}
+		if fence != "" {
+			// A closing fence is at least as long as the opening one
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			continue
+		}
+		if trimmed == markdownExampleHeading {
+			index = i
+		}
+	}
+	if index < 0 && fence != "" {
+		// An unterminated fence would hide the heading, so take it anyway
+		return last
+	}
+	return index
+}
+
+// parseMarkdownFence returns the content of the first fenced code block in
+// text, or the trimmed text itself if it isn't fenced
+func parseMarkdownFence(text string) string {
+	text = strings.TrimSpace(text)
+	lines := strings.Split(text, "\n")
+	fence := strings.TrimRight(lines[0], " ")
+	if !strings.HasPrefix(fence, "```") {
+		return text
+	}
+	fence = fence[:len(fence)-len(strings.TrimLeft(fence, "`"))]
+
+	for i := len(lines) - 1; i > 0; i-- {
+		if strings.TrimSpace(lines[i]) == fence {
+			return strings.Join(lines[1:i], "\n")
+		}
+	}
+	return strings.Join(lines[1:], "\n")
+}
+
+func (markdownBackend) save(location projectLocation, projectData ProjectData) error {
+	written := make(map[string]bool)
+
+	info, _ := toStringMap(projectData["info"])
+	if err := writeMarkdownNode(filepath.Join(location.Path, markdownIndexFile), info, written); err != nil {
+		return err
+	}
+
+	// Includes belong to the yaml backend, the project is stored assembled
+	keys := make(map[string]interface{})
+	for key, value := range projectData {
+		if key == "info" || key == "include" {
+			continue
+		}
+		if _, ok := toStringMap(value); !ok {
+			return fmt.Errorf("top-level value '%s' is not a key and cannot be stored as a Markdown file", key)
+		}
+		keys[key] = value
+	}
+	if err := writeMarkdownKeys(location.Path, keys, written); err != nil {
+		return err
+	}
+
+	return removeStaleMarkdownFiles(location.Path, written)
+}
+
+func writeMarkdownKeys(dir string, keys map[string]interface{}, written map[string]bool) error {
+	for key, value := range keys {
+		node, ok := toStringMap(value)
+		if !ok {
+			return fmt.Errorf("value of key '%s' is not a key and cannot be stored as a Markdown file", key)
+		}
+		if key == "" || strings.ContainsAny(key, "/\\") || strings.HasPrefix(key, ".") || key+".md" == markdownIndexFile {
+			return fmt.Errorf("key '%s' cannot be stored as a Markdown file", key)
+		}
+
+		if err := writeMarkdownNode(filepath.Join(dir, key+".md"), node, written); err != nil {
+			return err
+		}
+		if subKeys, ok := toStringMap(node["keys"]); ok && len(subKeys) > 0 {
+			if isMarkdownProject(filepath.Join(dir, key)) {
+				return fmt.Errorf("sub-keys of '%s' cannot be stored, %s holds a nested project", key, filepath.Join(dir, key))
+			}
+			if err := writeMarkdownKeys(filepath.Join(dir, key), subKeys, written); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeMarkdownNode renders a key node as front matter, body and example
+func writeMarkdownNode(filename string, node map[string]interface{}, written map[string]bool) error {
+	frontMatter := make(map[string]interface{})
+	for k, v := range node {
+		if k == "keys" || k == "infoLong" || k == "example" || v == "" {
+			continue
+		}
+		frontMatter[k] = v
+	}
+	data := keyDataFromNode(node)
+
+	var sb strings.Builder
+	if len(frontMatter) > 0 {
+		out, err := yaml.Marshal(frontMatter)
+		if err != nil {
+			return err
+		}
+		sb.WriteString("---\n")
+		sb.Write(out)
+		sb.WriteString("---\n")
+	}
+	if data.InfoLong != "" {
+		sb.WriteString("\n" + data.InfoLong + "\n")
+	}
+	// An empty example section keeps an "## Example" line of infoLong in the body
+	if data.Example != "" || markdownExampleIndex(strings.Split(data.InfoLong, "\n")) >= 0 {
+		fence := markdownFence(data.Example)
+		sb.WriteString("\n" + markdownExampleHeading + "\n\n" + fence + "\n" + strings.TrimSuffix(data.Example, "\n") + "\n" + fence + "\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	written[filename] = true
+	return ioutil.WriteFile(filename, []byte(sb.String()), 0644)
+}
+
+// markdownFence returns a backtick fence longer than any backtick run in text
+func markdownFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// removeStaleMarkdownFiles deletes key files of keys that no longer exist,
+// together with directories left empty. Nested projects are left alone.
+func removeStaleMarkdownFiles(projectDir string, written map[string]bool) error {
+	var dirs []string
+	err := filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != projectDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path != projectDir && info.IsDir() && isMarkdownProject(path) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		if filepath.Ext(path) == ".md" && !written[path] {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Deepest directories first so parents become empty in turn
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		if dir == projectDir {
+			continue
+		}
+		if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) == 0 {
+			os.Remove(dir)
+		}
+	}
+	return nil
+}
+
+// remove deletes the files of the project, keeping projects nested below it
+func (markdownBackend) remove(location projectLocation) error {
+	if err := removeStaleMarkdownFiles(location.Path, map[string]bool{}); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if entries, err := ioutil.ReadDir(location.Path); err == nil && len(entries) == 0 {
+		return os.Remove(location.Path)
+	}
+	return nil
+}
+
+func (markdownBackend) listProjects(storeDir, namespace string) ([]string, error) {
+	root := storeDir
+	if namespace != "" {
+		root = filepath.Join(storeDir, filepath.FromSlash(namespace))
+	}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var projects []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, markdownIndexFile)); err != nil {
+			// Not a project, but may be a namespace
+			return nil
+		}
+
+		rel, err := filepath.Rel(storeDir, path)
+		if err != nil {
+			return err
+		}
+		project := filepath.ToSlash(rel)
+		if validateProjectName(project) == nil {
+			projects = append(projects, project)
+		}
+		// Sub-directories hold the project's keys, or nested projects
+		return nil
+	})
+	sort.Strings(projects)
+	return projects, err
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownBackendRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	location := projectLocation{Store: dir, Project: "app", Backend: markdownBackend{}}
+	location.Path, _ = markdownBackend{}.projectPath(dir, "app")
+
+	projectData := ProjectData{
+		"info": map[string]interface{}{"infoShort": "My app", "infoLong": "Line one\n\nLine two"},
+		"docs": map[string]interface{}{
+			"infoShort": "Writing docs",
+			"infoLong":  "Entries look like:\n\n```\n## Example\n```",
+			"example":   "# Title\n\n## Example\n\n```sh\nmake docs\n```",
+			"tags":      []interface{}{"md"},
+			"keys": map[string]interface{}{
+				"api": map[string]interface{}{"infoShort": "API docs", "example": "~~~\n## Example\n~~~"},
+			},
+		},
+		"build":  map[string]interface{}{"infoLong": "Example: run make", "example": "make"},
+		"format": map[string]interface{}{"infoLong": "Format:\n\n## Example\n\nput code here"},
+	}
+	if err := (markdownBackend{}).save(location, projectData); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving again must not change what was read
+	for i := 0; i < 2; i++ {
+		loaded, err := markdownBackend{}.load(location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(normalizeValue(loaded), normalizeValue(projectData)) {
+			t.Fatalf("round trip %d:\n got %#v\nwant %#v", i+1, normalizeValue(loaded), normalizeValue(projectData))
+		}
+		if err := (markdownBackend{}).save(location, loaded); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMarkdownBackendKeepsNestedProjects(t *testing.T) {
+	dir := t.TempDir()
+	backend := markdownBackend{}
+	locate := func(project string) projectLocation {
+		path, err := backend.projectPath(dir, project)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return projectLocation{Store: dir, Project: project, Path: path, Backend: backend}
+	}
+	work, api := locate("work"), locate("work/api")
+
+	apiData := ProjectData{
+		"info":     map[string]interface{}{"infoShort": "API"},
+		"handlers": map[string]interface{}{"infoShort": "h", "keys": map[string]interface{}{"auth": map[string]interface{}{"infoShort": "a"}}},
+	}
+	if err := backend.save(api, apiData); err != nil {
+		t.Fatal(err)
+	}
+	// Saving the outer project twice, the second time without a key, cleans up its own files only
+	workData := ProjectData{"info": map[string]interface{}{"infoShort": "Work"}, "api": map[string]interface{}{"infoShort": "key named like the project"}, "old": map[string]interface{}{"infoShort": "o"}}
+	if err := backend.save(work, workData); err != nil {
+		t.Fatal(err)
+	}
+	delete(workData, "old")
+	if err := backend.save(work, workData); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		location projectLocation
+		want     ProjectData
+	}{{work, workData}, {api, apiData}} {
+		loaded, err := backend.load(tt.location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(normalizeValue(loaded), normalizeValue(tt.want)) {
+			t.Errorf("%s = %v, want %v", tt.location.Project, normalizeValue(loaded), normalizeValue(tt.want))
+		}
+	}
+
+	projects, err := backend.listProjects(dir, "")
+	if err != nil || strings.Join(projects, " ") != "work work/api" {
+		t.Errorf("listProjects = %v, %v", projects, err)
+	}
+
+	// Sub-keys of a key can't share the directory of a nested project
+	workData["api"] = map[string]interface{}{"keys": map[string]interface{}{"x": map[string]interface{}{"infoShort": "x"}}}
+	if err := backend.save(work, workData); err == nil {
+		t.Error("sub-keys were written into a nested project")
+	}
+
+	// Removing the outer project, e.g. by --undo of its creation, keeps the nested one
+	if err := backend.remove(work); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ := backend.load(work); len(loaded) != 0 {
+		t.Errorf("removed project loads as %v", loaded)
+	}
+	if loaded, _ := backend.load(api); getKeyData(loaded, "handlers.keys.auth").InfoShort != "a" {
+		t.Errorf("nested project after removing its parent = %v", loaded)
+	}
+	if err := backend.remove(api); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(api.Path); !os.IsNotExist(err) {
+		t.Errorf("%s is left after removing the project: %v", api.Path, err)
+	}
+}
+
+func TestMarkdownBackendRejectsTopLevelValues(t *testing.T) {
+	dir := t.TempDir()
+	location := projectLocation{Store: dir, Project: "app", Path: filepath.Join(dir, "app"), Backend: markdownBackend{}}
+	if err := (markdownBackend{}).save(location, ProjectData{"tags": []interface{}{"a"}}); err == nil || !strings.Contains(err.Error(), "tags") {
+		t.Errorf("saving a top-level list: %v", err)
+	}
+	// Includes are resolved by the yaml backend, the keys themselves are stored
+	if err := (markdownBackend{}).save(location, ProjectData{"include": "app.d/*.yaml", "db": map[string]interface{}{"infoShort": "d"}}); err != nil {
+		t.Errorf("saving a project with includes: %v", err)
+	}
+}
+
+func TestMarkdownExampleIndex(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  int
+	}{
+		{[]string{"text"}, -1},
+		{[]string{"text", "## Example", "```", "make", "```"}, 1},
+		{[]string{"## Example", "", "````", "## Example", "```", "````"}, 0},
+		{[]string{"```", "## Example", "```", "## Example", "~~~", "## Example", "~~~"}, 3},
+		{[]string{"Use ``` for code", "## Example", "make"}, 1}, // unterminated fence
+	}
+	for _, tt := range tests {
+		if got := markdownExampleIndex(tt.lines); got != tt.want {
+			t.Errorf("markdownExampleIndex(%q) = %d, want %d", tt.lines, got, tt.want)
+		}
+	}
+}
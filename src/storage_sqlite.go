@@ -0,0 +1,296 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"gopkg.in/yaml.v2"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDatabaseFile is the database holding every project of a store
+const sqliteDatabaseFile = "recall.db"
+
+// sqliteSchema stores one row per key node. path is the key path joined with
+// '/', the project info has the empty path. extra holds any fields besides the
+// info fields as YAML. keys_fts indexes the info fields for --search; its
+// trigram tokenizer matches substrings, like searching the other backends.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS keys (
+	project    TEXT NOT NULL,
+	path       TEXT NOT NULL,
+	info_short TEXT NOT NULL DEFAULT '',
+	info_long  TEXT NOT NULL DEFAULT '',
+	example    TEXT NOT NULL DEFAULT '',
+	extra      TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (project, path)
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS keys_fts USING fts5(
+	project UNINDEXED,
+	path,
+	info_short,
+	info_long,
+	example,
+	tokenize = 'trigram'
+);
+`
+
+// sqliteTrigramLength is the shortest query the trigram index can answer
+const sqliteTrigramLength = 3
+
+// sqliteBackend stores all projects of a store in <store>/recall.db
+type sqliteBackend struct{}
+
+func (sqliteBackend) projectPath(storeDir, project string) (string, error) {
+	if _, err := projectBasePath(storeDir, project); err != nil {
+		return "", err
+	}
+	return filepath.Join(storeDir, sqliteDatabaseFile), nil
+}
+
+// openSQLiteStore opens the database at filename, creating it if create is set.
+// It returns a nil database if it doesn't exist and create is not set.
+func openSQLiteStore(filename string, create bool) (*sql.DB, error) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		if !create {
+			return nil, nil
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %v", filename, err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not initialize %s: %v", filename, err)
+	}
+	return db, nil
+}
+
+func (sqliteBackend) load(location projectLocation) (ProjectData, error) {
+	projectData := make(ProjectData)
+
+	db, err := openSQLiteStore(location.Path, false)
+	if err != nil || db == nil {
+		return projectData, err
+	}
+	defer db.Close()
+
+	// Ordering by path creates parents before their sub-keys
+	rows, err := db.Query(`SELECT path, info_short, info_long, example, extra FROM keys WHERE project = ? ORDER BY path`, location.Project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path, extra string
+		var data KeyData
+		if err := rows.Scan(&path, &data.InfoShort, &data.InfoLong, &data.Example, &extra); err != nil {
+			return nil, err
+		}
+
+		node := make(map[string]interface{})
+		if extra != "" {
+			if err := yaml.Unmarshal([]byte(extra), &node); err != nil {
+				return nil, fmt.Errorf("could not parse fields of '%s' in %s: %v", path, location.Path, err)
+			}
+		}
+		node["infoShort"] = data.InfoShort
+		node["infoLong"] = data.InfoLong
+		node["example"] = data.Example
+
+		if path == "" {
+			projectData["info"] = node
+			continue
+		}
+		insertNode(projectData, strings.Split(path, "/"), node)
+	}
+	return projectData, rows.Err()
+}
+
+// insertNode places node at keyPath, creating missing parents
+func insertNode(projectData ProjectData, keyPath []string, node map[string]interface{}) {
+	current := map[string]interface{}(projectData)
+	for i, key := range keyPath {
+		if i == len(keyPath)-1 {
+			if existing, ok := toStringMap(current[key]); ok {
+				// Keep sub-keys that were inserted before this node
+				if subKeys, ok := existing["keys"]; ok {
+					node["keys"] = subKeys
+				}
+			}
+			current[key] = node
+			return
+		}
+
+		parent, ok := toStringMap(current[key])
+		if !ok {
+			parent = make(map[string]interface{})
+		}
+		current[key] = parent
+		subKeys, ok := toStringMap(parent["keys"])
+		if !ok {
+			subKeys = make(map[string]interface{})
+		}
+		parent["keys"] = subKeys
+		current = subKeys
+	}
+}
+
+func (sqliteBackend) save(location projectLocation, projectData ProjectData) error {
+	db, err := openSQLiteStore(location.Path, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM keys WHERE project = ?`, location.Project); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM keys_fts WHERE project = ?`, location.Project); err != nil {
+		return err
+	}
+
+	var walkErr error
+	walkNodes(projectData, func(keyPath []string, node map[string]interface{}) {
+		if walkErr != nil {
+			return
+		}
+		for _, key := range keyPath {
+			if strings.Contains(key, "/") {
+				walkErr = fmt.Errorf("key '%s' cannot be stored in SQLite: it contains '/'", key)
+				return
+			}
+		}
+		path := strings.Join(keyPath, "/")
+
+		extra := ""
+		extraFields := make(map[string]interface{})
+		for k, v := range node {
+			if k != "keys" && k != "infoShort" && k != "infoLong" && k != "example" {
+				extraFields[k] = v
+			}
+		}
+		if len(extraFields) > 0 {
+			out, err := yaml.Marshal(extraFields)
+			if err != nil {
+				walkErr = err
+				return
+			}
+			extra = string(out)
+		}
+
+		data := keyDataFromNode(node)
+		if _, err := tx.Exec(`INSERT INTO keys (project, path, info_short, info_long, example, extra) VALUES (?, ?, ?, ?, ?, ?)`,
+			location.Project, path, data.InfoShort, data.InfoLong, data.Example, extra); err != nil {
+			walkErr = err
+			return
+		}
+		if _, err := tx.Exec(`INSERT INTO keys_fts (project, path, info_short, info_long, example) VALUES (?, ?, ?, ?, ?)`,
+			location.Project, path, data.InfoShort, data.InfoLong, data.Example); err != nil {
+			walkErr = err
+		}
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+	return tx.Commit()
+}
+
+func (sqliteBackend) remove(location projectLocation) error {
+	db, err := openSQLiteStore(location.Path, false)
+	if err != nil || db == nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`DELETE FROM keys WHERE project = ?`, location.Project); err != nil {
+		return err
+	}
+	_, err = db.Exec(`DELETE FROM keys_fts WHERE project = ?`, location.Project)
+	return err
+}
+
+func (sqliteBackend) listProjects(storeDir, namespace string) ([]string, error) {
+	db, err := openSQLiteStore(filepath.Join(storeDir, sqliteDatabaseFile), false)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT DISTINCT project FROM keys WHERE ? = '' OR project = ? OR substr(project, 1, length(?) + 1) = ? || '/' ORDER BY project`,
+		namespace, namespace, namespace, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []string
+	for rows.Next() {
+		var project string
+		if err := rows.Scan(&project); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+// searchKeys answers --search from the full text index instead of loading
+// every project. Like for the other backends, query matches as a substring.
+func (sqliteBackend) searchKeys(storeDir, namespace, query string) ([]searchResult, error) {
+	db, err := openSQLiteStore(filepath.Join(storeDir, sqliteDatabaseFile), false)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// The trigram index needs at least three characters, shorter queries
+	// scan the keys table instead
+	table, match := "keys", "1"
+	var args []interface{}
+	if len([]rune(query)) >= sqliteTrigramLength {
+		table, match = "keys_fts", "keys_fts MATCH ?"
+		args = append(args, `"`+strings.ReplaceAll(query, `"`, `""`)+`"`)
+	}
+	args = append(args, namespace, namespace, namespace, namespace)
+	rows, err := db.Query(`SELECT project, path, info_short, info_long, example FROM `+table+`
+		WHERE `+match+` AND (? = '' OR project = ? OR substr(project, 1, length(?) + 1) = ? || '/')
+		ORDER BY project, path`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []searchResult
+	for rows.Next() {
+		var result searchResult
+		var path string
+		if err := rows.Scan(&result.Project, &path, &result.Data.InfoShort, &result.Data.InfoLong, &result.Data.Example); err != nil {
+			return nil, err
+		}
+		result.KeyPath = []string{}
+		if path != "" {
+			result.KeyPath = strings.Split(path, "/")
+		}
+		// The index folds case a little differently than Go does
+		if keyMatches(result.KeyPath, result.Data, query) {
+			results = append(results, result)
+		}
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// searchTestData is saved to every backend to compare their search results
+var searchTestData = ProjectData{
+	"info": map[string]interface{}{"infoShort": "Backend services"},
+	"database": map[string]interface{}{
+		"infoShort": "Database connection pool",
+		"example":   "db.Connect(dsn)",
+		"keys": map[string]interface{}{
+			"migrations": map[string]interface{}{"infoLong": "Run with make migrate"},
+		},
+	},
+	"cache": map[string]interface{}{"infoShort": "Redis CACHE for sessions"},
+}
+
+func searchTestKeys(t *testing.T, settings *Settings, storeDir, query string) []string {
+	t.Helper()
+	results, err := searchStore(settings, storeDir, "", query)
+	if err != nil {
+		t.Fatalf("search %q: %v", query, err)
+	}
+	keys := []string{}
+	for _, result := range results {
+		keys = append(keys, result.Project+":"+keyPathString(result.KeyPath))
+	}
+	return keys
+}
+
+func TestSQLiteSearchMatchesSubstringsLikeYAML(t *testing.T) {
+	yamlDir, sqliteDir := t.TempDir(), t.TempDir()
+	yamlSettings := &Settings{Storage: StorageSettings{Global: "yaml"}}
+	sqliteSettings := &Settings{Storage: StorageSettings{Global: "sqlite"}}
+	for _, store := range []struct {
+		settings *Settings
+		dir      string
+	}{{yamlSettings, yamlDir}, {sqliteSettings, sqliteDir}} {
+		location, err := locateProject(store.settings, store.dir, "api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := location.Backend.save(location, searchTestData); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, query := range []string{"connect", "abase conn", "ache", "cache", "db", "grat", "make migrate", "sessions redis", "x", "(dsn)"} {
+		want := searchTestKeys(t, yamlSettings, yamlDir, query)
+		got := searchTestKeys(t, sqliteSettings, sqliteDir, query)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sqlite search %q = %v, yaml found %v", query, got, want)
+		}
+	}
+}
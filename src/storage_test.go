@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectFileFallsBackToGlobalStore(t *testing.T) {
+	useTempStores(t)
+	settings := defaultSettings()
+	writeTestFile(t, filepath.Join(localStoreDir, "local.yaml"), "db:\n  infoShort: local\n")
+	writeTestFile(t, filepath.Join(globalStoreDir(), "global.yaml"), "db:\n  infoShort: global\n")
+	writeTestFile(t, filepath.Join(globalStoreDir(), "local.yaml"), "db:\n  infoShort: shadowed\n")
+
+	tests := []struct {
+		project string
+		store   string
+	}{
+		{"local", localStoreDir},     // found locally
+		{"global", globalStoreDir()}, // only in the global store
+		{"new", localStoreDir},       // created in the local store
+	}
+	for _, tt := range tests {
+		location, err := findProjectFile(settings, tt.project)
+		if err != nil {
+			t.Fatalf("findProjectFile(%s): %v", tt.project, err)
+		}
+		if location.Store != tt.store {
+			t.Errorf("findProjectFile(%s) store = %s, want %s", tt.project, location.Store, tt.store)
+		}
+	}
+}
+
+func TestFindProjectFileWithoutLocalStore(t *testing.T) {
+	useTempStores(t)
+	location, err := findProjectFile(defaultSettings(), "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Store != globalStoreDir() {
+		t.Errorf("store = %s, want %s", location.Store, globalStoreDir())
+	}
+}
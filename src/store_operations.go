@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// storeDirs returns the existing stores, local before global
+func storeDirs() []string {
+	var dirs []string
+	for _, dir := range []string{localStoreDir, globalStoreDir()} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// parseNamespace validates a namespace argument such as "work/" and returns
+// it without the trailing slash. An empty argument selects every project.
+func parseNamespace(arg string) (string, error) {
+	namespace := strings.TrimSuffix(arg, "/")
+	if namespace == "" {
+		return "", nil
+	}
+	if err := validateProjectName(namespace); err != nil {
+		return "", err
+	}
+	return namespace, nil
+}
+
+// listProjects returns the names of all projects in storeDir below namespace, sorted
+func listProjects(settings *Settings, storeDir, namespace string) ([]string, error) {
+	backend, err := storeBackend(settings, storeDir)
+	if err != nil {
+		return nil, err
+	}
+	return backend.listProjects(storeDir, namespace)
+}
+
+// listCommand prints the projects of every store, optionally limited to a namespace
+func listCommand(settings *Settings, namespaceArg string) {
+	namespace, err := parseNamespace(namespaceArg)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+
+	found := false
+	for _, storeDir := range storeDirs() {
+		projects, err := listProjects(settings, storeDir, namespace)
+		if err != nil {
+			fmt.Printf("[ERROR] Could not list %s: %v\n", storeDir, err)
+			continue
+		}
+		if len(projects) == 0 {
+			continue
+		}
+		found = true
+		fmt.Printf("\033[1;32m%s:\033[0m\n", storeDir)
+		for _, project := range projects {
+			fmt.Printf("  • %s\n", project)
+		}
+	}
+
+	if !found {
+		if namespace == "" {
+			fmt.Println("[INFO] No projects found. Use --edit to create one.")
+		} else {
+			fmt.Printf("[INFO] No projects found in namespace '%s/'\n", namespace)
+		}
+	}
+}
+
+// searchResult is a key matching a --search query
+type searchResult struct {
+	Project string
+	KeyPath []string
+	Data    KeyData
+}
+
+// keySearcher is implemented by storage backends that maintain their own
+// search index; other stores are searched by loading every project
+type keySearcher interface {
+	searchKeys(storeDir, namespace, query string) ([]searchResult, error)
+}
+
+// searchStore returns the keys of storeDir whose name or info fields contain
+// query (case-insensitive), limited to the projects of namespace
+func searchStore(settings *Settings, storeDir, namespace, query string) ([]searchResult, error) {
+	backend, err := storeBackend(settings, storeDir)
+	if err != nil {
+		return nil, err
+	}
+	if searcher, ok := backend.(keySearcher); ok {
+		return searcher.searchKeys(storeDir, namespace, query)
+	}
+
+	projects, err := backend.listProjects(storeDir, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []searchResult
+	for _, project := range projects {
+		projectFile, err := locateProject(settings, storeDir, project)
+		if err != nil {
+			continue
+		}
+		walkKeys(loadProjectData(projectFile), func(keyPath []string, data KeyData) {
+			if keyMatches(keyPath, data, query) {
+				results = append(results, searchResult{Project: project, KeyPath: keyPath, Data: data})
+			}
+		})
+	}
+	return results, nil
+}
+
+// keyMatches reports whether the name or an info field of a key contains
+// query, ignoring case
+func keyMatches(keyPath []string, data KeyData, query string) bool {
+	fields := []string{strings.Join(keyPath, "\n"), data.InfoShort, data.InfoLong, data.Example}
+	return strings.Contains(strings.ToLower(strings.Join(fields, "\n")), strings.ToLower(query))
+}
+
+// searchCommand prints every key matching query, optionally limited to the
+// projects of a namespace
+func searchCommand(settings *Settings, query, namespaceArg string) {
+	namespace, err := parseNamespace(namespaceArg)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+
+	matches := 0
+	for _, storeDir := range storeDirs() {
+		results, err := searchStore(settings, storeDir, namespace, query)
+		if err != nil {
+			fmt.Printf("[ERROR] Could not search %s: %v\n", storeDir, err)
+			continue
+		}
+		for _, result := range results {
+			matches++
+			if len(result.KeyPath) == 0 {
+				fmt.Printf("\033[1;32m%s\033[0m (general info)\n", result.Project)
+			} else {
+				fmt.Printf("\033[1;32m%s\033[0m %s\n", result.Project, strings.Join(result.KeyPath, " → "))
+			}
+			if result.Data.InfoShort != "" {
+				fmt.Printf("  %s\n", result.Data.InfoShort)
+			}
+		}
+	}
+
+	if matches == 0 {
+		fmt.Printf("[INFO] No keys matching '%s' found\n", query)
+	}
+}
+
+// migrateCommand copies every project of a store from one storage backend to
+// another, e.g. "yaml-to-sqlite". The source is left untouched.
+func migrateCommand(settings *Settings, direction, storeArg string) {
+	parts := strings.Split(direction, "-to-")
+	if len(parts) != 2 {
+		fmt.Printf("[ERROR] Invalid migration '%s', expected <from>-to-<to> (e.g. yaml-to-sqlite)\n", direction)
+		return
+	}
+	from, okFrom := storageBackends[parts[0]]
+	to, okTo := storageBackends[parts[1]]
+	if !okFrom || !okTo || parts[0] == parts[1] {
+		fmt.Printf("[ERROR] Invalid migration '%s', backends are yaml, markdown and sqlite\n", direction)
+		return
+	}
+
+	var storeDir, storeName string
+	switch storeArg {
+	case "local":
+		storeDir, storeName = localStoreDir, "local"
+	case "global":
+		storeDir, storeName = globalStoreDir(), "global"
+	case "":
+		storeDir, storeName = globalStoreDir(), "global"
+		if _, err := os.Stat(localStoreDir); err == nil {
+			storeDir, storeName = localStoreDir, "local"
+		}
+	default:
+		fmt.Printf("[ERROR] Unknown store '%s', expected local or global\n", storeArg)
+		return
+	}
+
+	projects, err := from.listProjects(storeDir, "")
+	if err != nil {
+		fmt.Printf("[ERROR] Could not list %s: %v\n", storeDir, err)
+		return
+	}
+	if len(projects) == 0 {
+		fmt.Printf("[INFO] No %s projects found in %s\n", parts[0], storeDir)
+		return
+	}
+
+	for _, project := range projects {
+		source, err := from.projectPath(storeDir, project)
+		if err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+		target, err := to.projectPath(storeDir, project)
+		if err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+
+		projectData, err := from.load(projectLocation{Store: storeDir, Project: project, Path: source, Backend: from})
+		if err != nil {
+			fmt.Printf("[ERROR] Could not load %s: %v\n", project, err)
+			return
+		}
+		if err := to.save(projectLocation{Store: storeDir, Project: project, Path: target, Backend: to}, projectData); err != nil {
+			fmt.Printf("[ERROR] Could not save %s: %v\n", project, err)
+			return
+		}
+		fmt.Printf("[INFO] Migrated %s → %s\n", source, target)
+	}
+
+	fmt.Printf("[INFO] Migrated %d project(s). Set storage.%s: %s in %s to use them.\n", len(projects), storeName, parts[1], settingsFilePath())
+	fmt.Printf("[INFO] The %s files were left in place; remove them once you have verified the migration.\n", parts[0])
+}
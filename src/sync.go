@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"gopkg.in/yaml.v2"
+)
+
+// syncIgnored are the files of the global store that stay on each machine,
+// like the settings with the editor
+var syncIgnored = []string{"settings.yaml"}
+
+// syncCommand synchronizes the global store with settings.SyncRemote: local
+// changes are committed, rebased onto the remote branch and pushed. Conflicts
+// in project files of any storage backend are resolved per key by merging the
+// parsed project data; when both sides changed the same field, the local
+// version wins.
+func syncCommand(settings *Settings) {
+	if settings.SyncRemote == "" {
+		fmt.Printf("[ERROR] No sync remote configured. Set syncRemote in %s\n", settingsFilePath())
+		return
+	}
+	branch := settings.SyncBranch
+	if branch == "" {
+		branch = "main"
+	}
+
+	storeDir := globalStoreDir()
+	if err := os.MkdirAll(storeDir, 0755); err != nil {
+		fmt.Printf("[ERROR] Error creating %s: %v\n", storeDir, err)
+		return
+	}
+	if err := prepareSyncRepository(storeDir, settings.SyncRemote, branch); err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+
+	// 1.) Commit local changes
+	if _, err := runGit(storeDir, "add", "-A"); err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	if status, _ := runGit(storeDir, "status", "--porcelain"); status != "" {
+		hostname, _ := os.Hostname()
+		if _, err := runGit(storeDir, gitIdentityArgs(storeDir, "commit", "--quiet", "-m", "recall: sync from "+hostname)...); err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+		fmt.Println("[INFO] Committed local changes")
+	}
+
+	// 2.) Fetch the remote branch
+	if _, err := runGit(storeDir, "fetch", "--quiet", "origin"); err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	remoteBranch := "origin/" + branch
+	_, remoteErr := runGit(storeDir, "rev-parse", "--verify", "--quiet", remoteBranch)
+	_, localErr := runGit(storeDir, "rev-parse", "--verify", "--quiet", "HEAD")
+
+	switch {
+	case remoteErr != nil && localErr != nil:
+		fmt.Println("[INFO] Nothing to sync yet")
+		return
+	case localErr != nil:
+		// Fresh machine: take over the remote history
+		if _, err := runGit(storeDir, "checkout", "--quiet", "-B", branch, remoteBranch); err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+		fmt.Printf("[INFO] Checked out %s from %s\n", branch, settings.SyncRemote)
+		return
+	case remoteErr == nil:
+		// 3.) Rebase local commits onto the remote branch
+		if err := rebaseStore(storeDir, remoteBranch); err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+	}
+
+	// 4.) Push
+	if _, err := runGit(storeDir, "push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	fmt.Printf("[INFO] Synchronized %s with %s\n", storeDir, settings.SyncRemote)
+}
+
+// prepareSyncRepository makes storeDir a git repository of its own with
+// remote as origin
+func prepareSyncRepository(storeDir, remote, branch string) error {
+	absStore, err := filepath.Abs(storeDir)
+	if err != nil {
+		return err
+	}
+	if topLevel, err := runGit(storeDir, "rev-parse", "--show-toplevel"); err != nil || filepath.Clean(topLevel) != absStore {
+		if _, err := runGit(storeDir, "init", "--quiet"); err != nil {
+			return err
+		}
+		if _, err := runGit(storeDir, "symbolic-ref", "HEAD", "refs/heads/"+branch); err != nil {
+			return err
+		}
+		fmt.Printf("[INFO] Initialized git repository in %s\n", storeDir)
+	}
+	if err := excludeSyncIgnored(storeDir); err != nil {
+		return err
+	}
+
+	if current, err := runGit(storeDir, "remote", "get-url", "origin"); err != nil {
+		_, err = runGit(storeDir, "remote", "add", "origin", remote)
+		return err
+	} else if current != remote {
+		_, err = runGit(storeDir, "remote", "set-url", "origin", remote)
+		return err
+	}
+	return nil
+}
+
+// excludeSyncIgnored keeps the files of syncIgnored out of the repository.
+// They are listed in .git/info/exclude rather than a .gitignore, which would
+// itself be synchronized and conflict between fresh machines.
+func excludeSyncIgnored(storeDir string) error {
+	excludeFile, err := runGit(storeDir, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(excludeFile) {
+		excludeFile = filepath.Join(storeDir, excludeFile)
+	}
+
+	existing, _ := ioutil.ReadFile(excludeFile)
+	listed := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		listed[strings.TrimSpace(line)] = true
+	}
+	content := string(existing)
+	for _, name := range syncIgnored {
+		pattern := "/" + name
+		if listed[pattern] {
+			continue
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += pattern + "\n"
+	}
+	if content == string(existing) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(excludeFile), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(excludeFile, []byte(content), 0644)
+}
+
+// gitIdentityArgs prefixes a git command with a fallback identity if none is
+// configured, so syncing works on fresh machines
+func gitIdentityArgs(dir string, args ...string) []string {
+	if email, _ := runGit(dir, "config", "user.email"); email != "" {
+		return args
+	}
+	hostname, _ := os.Hostname()
+	return append([]string{"-c", "user.name=recall", "-c", "user.email=recall@" + hostname}, args...)
+}
+
+// rebaseStore rebases the local commits onto upstream, resolving conflicts
+// in project files per key. Conflicts in other files abort the rebase.
+func rebaseStore(storeDir, upstream string) error {
+	// Keep the messages of replayed commits without opening an editor
+	env := []string{"GIT_EDITOR=true"}
+	_, err := runGitEnv(storeDir, env, gitIdentityArgs(storeDir, "rebase", "--quiet", upstream)...)
+
+	// Each iteration resolves the conflicts of one replayed commit
+	for attempt := 0; err != nil; attempt++ {
+		conflicted, listErr := runGit(storeDir, "diff", "--name-only", "--diff-filter=U")
+		if listErr != nil || conflicted == "" || attempt > 1000 {
+			runGit(storeDir, "rebase", "--abort")
+			return fmt.Errorf("rebase failed: %v", err)
+		}
+
+		for _, file := range strings.Split(conflicted, "\n") {
+			if resolveErr := resolveSyncConflict(storeDir, file); resolveErr != nil {
+				runGit(storeDir, "rebase", "--abort")
+				return resolveErr
+			}
+		}
+
+		if staged, _ := runGit(storeDir, "diff", "--cached", "--name-only"); staged == "" {
+			// The local commit is fully contained in upstream
+			_, err = runGitEnv(storeDir, env, "rebase", "--skip")
+		} else {
+			_, err = runGitEnv(storeDir, env, gitIdentityArgs(storeDir, "rebase", "--continue")...)
+		}
+	}
+	return nil
+}
+
+// resolveSyncConflict merges the index stages of a conflicted file of the
+// store, by the storage backend it belongs to. While rebasing, stage 2 is the
+// remote version and stage 3 the local commit being replayed.
+func resolveSyncConflict(storeDir, file string) error {
+	switch {
+	case strings.HasPrefix(file, historyDir+"/"):
+		return resolveHistoryConflict(storeDir, file)
+	case filepath.Base(file) == sqliteDatabaseFile:
+		return resolveSQLiteConflict(storeDir, file)
+	case filepath.Ext(file) == ".md":
+		return resolveMarkdownConflict(storeDir, file)
+	case filepath.Ext(file) == ".yaml":
+		return resolveYAMLConflict(storeDir, file)
+	}
+	return fmt.Errorf("cannot merge %s automatically, resolve the conflict in %s manually", file, storeDir)
+}
+
+// conflictStage returns the content of a conflicted file in index stage n
+// (1 base, 2 remote, 3 local), or false if the file didn't exist on that side
+func conflictStage(storeDir, file string, n int) ([]byte, bool) {
+	cmd := exec.Command("git", "show", fmt.Sprintf(":%d:%s", n, file))
+	cmd.Dir = storeDir
+	content, err := cmd.Output()
+	return content, err == nil
+}
+
+// mergeSyncData merges the stages of a conflicted file, keeping the local
+// version of fields changed on both sides
+func mergeSyncData(file string, base, remote, local ProjectData) ProjectData {
+	merged, conflicts := mergeProjectData(base, local, remote, func(conflict mergeConflict) interface{} {
+		return conflict.Ours
+	})
+	for _, conflict := range conflicts {
+		fmt.Printf("[WARN] %s: %s was changed on both sides, keeping the local version\n", file, conflict)
+	}
+	return merged
+}
+
+// resolveYAMLConflict merges a project file of the yaml backend
+func resolveYAMLConflict(storeDir, file string) error {
+	var stages [3]ProjectData
+	for i := range stages {
+		stages[i] = make(ProjectData)
+		if content, ok := conflictStage(storeDir, file, i+1); ok {
+			if err := yaml.Unmarshal(content, &stages[i]); err != nil {
+				return fmt.Errorf("could not parse %s: %v", file, err)
+			}
+		}
+	}
+	merged := mergeSyncData(file, stages[0], stages[1], stages[2])
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(storeDir, file), data, 0644); err != nil {
+		return err
+	}
+	_, err = runGit(storeDir, "add", "--", file)
+	return err
+}
+
+// resolveMarkdownConflict merges a key file of the markdown backend. Each file
+// holds a single key node, without its sub-keys.
+func resolveMarkdownConflict(storeDir, file string) error {
+	key := strings.TrimSuffix(filepath.Base(file), ".md")
+	var stages [3]ProjectData
+	for i := range stages {
+		stages[i] = make(ProjectData)
+		if content, ok := conflictStage(storeDir, file, i+1); ok {
+			node, err := parseMarkdownNode(string(content), file)
+			if err != nil {
+				return err
+			}
+			stages[i][key] = node
+		}
+	}
+	merged := mergeSyncData(file, stages[0], stages[1], stages[2])
+
+	node, ok := toStringMap(merged[key])
+	if !ok {
+		// Removed on one side and unchanged on the other
+		_, err := runGit(storeDir, "rm", "--quiet", "--", file)
+		return err
+	}
+	if err := writeMarkdownNode(filepath.Join(storeDir, file), node, make(map[string]bool)); err != nil {
+		return err
+	}
+	_, err := runGit(storeDir, "add", "--", file)
+	return err
+}
+
+// resolveSQLiteConflict merges the database of the sqlite backend project by
+// project and writes the result to a new database
+func resolveSQLiteConflict(storeDir, file string) error {
+	tempDir, err := ioutil.TempDir("", "recall-sync-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	var stages [3]projectLocation
+	projects := make(map[string]bool)
+	for i := range stages {
+		stages[i] = projectLocation{Path: filepath.Join(tempDir, fmt.Sprint(i+1), sqliteDatabaseFile), Backend: sqliteBackend{}}
+		content, ok := conflictStage(storeDir, file, i+1)
+		if !ok {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(stages[i].Path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(stages[i].Path, content, 0644); err != nil {
+			return err
+		}
+		names, err := sqliteBackend{}.listProjects(filepath.Dir(stages[i].Path), "")
+		if err != nil {
+			return fmt.Errorf("could not read %s: %v", file, err)
+		}
+		for _, name := range names {
+			projects[name] = true
+		}
+	}
+
+	target := filepath.Join(storeDir, file)
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for project := range projects {
+		var data [3]ProjectData
+		for i, stage := range stages {
+			stage.Project = project
+			if data[i], err = stage.Backend.load(stage); err != nil {
+				return fmt.Errorf("could not read %s from %s: %v", project, file, err)
+			}
+		}
+		merged := mergeSyncData(file+" "+project, data[0], data[1], data[2])
+		if len(merged) == 0 {
+			continue
+		}
+		location := projectLocation{Store: filepath.Dir(target), Project: project, Path: target, Backend: sqliteBackend{}}
+		if err := location.Backend.save(location, merged); err != nil {
+			return err
+		}
+	}
+	_, err = runGit(storeDir, "add", "--", file)
+	return err
+}
+
+// resolveHistoryConflict merges change logs, which are only ever appended to:
+// the entries added locally are appended after the remote ones
+func resolveHistoryConflict(storeDir, file string) error {
+	base, _ := runGit(storeDir, "show", ":1:"+file)
+	remote, _ := runGit(storeDir, "show", ":2:"+file)
+	local, _ := runGit(storeDir, "show", ":3:"+file)
+
+	merged := mergeAppendOnly(base, remote, local)
+	if err := ioutil.WriteFile(filepath.Join(storeDir, file), []byte(merged), 0644); err != nil {
+		return err
+	}
+	_, err := runGit(storeDir, "add", "--", file)
+	return err
+}
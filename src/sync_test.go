@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// syncTestMachine is a home directory whose ~/.recall is a clone of the remote
+type syncTestMachine struct {
+	t        *testing.T
+	home     string
+	settings *Settings
+}
+
+func (m syncTestMachine) use() {
+	m.t.Setenv("HOME", m.home)
+	m.t.Setenv("XDG_CACHE_HOME", filepath.Join(m.home, ".cache"))
+}
+
+func (m syncTestMachine) edit(fn func(projectData ProjectData)) {
+	m.t.Helper()
+	m.use()
+	location, err := locateProject(m.settings, globalStoreDir(), "app")
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	projectData := loadProjectData(location)
+	fn(projectData)
+	if err := saveProjectData(location, projectData); err != nil {
+		m.t.Fatal(err)
+	}
+}
+
+func (m syncTestMachine) sync() string {
+	m.t.Helper()
+	m.use()
+	out := captureOutput(m.t, func() { syncCommand(m.settings) })
+	if strings.Contains(out, "[ERROR]") {
+		m.t.Fatalf("sync of %s failed:\n%s", m.home, out)
+	}
+	return out
+}
+
+func (m syncTestMachine) load() ProjectData {
+	m.t.Helper()
+	m.use()
+	location, err := locateProject(m.settings, globalStoreDir(), "app")
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return loadProjectData(location)
+}
+
+func TestSyncMergesConflictsPerKey(t *testing.T) {
+	for _, backend := range []string{"yaml", "markdown", "sqlite"} {
+		t.Run(backend, func(t *testing.T) { testSyncMergesConflictsPerKey(t, backend) })
+	}
+}
+
+func testSyncMergesConflictsPerKey(t *testing.T, backend string) {
+	remote := t.TempDir()
+	initTestRepo(t, remote, "--bare")
+	settings := &Settings{SyncRemote: remote, SyncBranch: "main", Storage: StorageSettings{Global: backend}}
+
+	var machines [2]syncTestMachine
+	for i := range machines {
+		machines[i] = syncTestMachine{t: t, home: t.TempDir(), settings: settings}
+		if _, err := runGit(machines[i].home, "clone", "--quiet", remote, ".recall"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, b := machines[0], machines[1]
+
+	a.edit(func(projectData ProjectData) {
+		setKeyData(projectData, "db", KeyData{InfoShort: "db"})
+		setKeyData(projectData, "api", KeyData{InfoShort: "api"})
+		setKeyData(projectData, "cache", KeyData{InfoShort: "cache"})
+	})
+	a.sync()
+	b.sync()
+
+	// Both machines change the api key, and one other key each
+	a.edit(func(projectData ProjectData) {
+		setKeyData(projectData, "db", KeyData{InfoShort: "db from a"})
+		setKeyData(projectData, "api", KeyData{InfoShort: "api from a", Example: "example from a"})
+	})
+	b.edit(func(projectData ProjectData) {
+		setKeyData(projectData, "cache", KeyData{InfoShort: "cache from b"})
+		setKeyData(projectData, "api", KeyData{InfoShort: "api from b"})
+	})
+	a.sync()
+	out := b.sync()
+	if !strings.Contains(out, "api (infoShort) was changed on both sides") {
+		t.Errorf("sync did not report the conflicting field:\n%s", out)
+	}
+	a.sync()
+
+	want := map[string]KeyData{
+		"db":    {InfoShort: "db from a"},
+		"api":   {InfoShort: "api from b", Example: "example from a"},
+		"cache": {InfoShort: "cache from b"},
+	}
+	for _, m := range machines {
+		projectData := m.load()
+		for key, data := range want {
+			if got := getKeyData(projectData, key); got != data {
+				t.Errorf("%s of %s = %+v, want %+v", key, m.home, got, data)
+			}
+		}
+
+		// Both sides' change logs are kept
+		history, err := os.ReadFile(filepath.Join(m.home, ".recall", historyDir, "app.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, text := range []string{"db from a", "cache from b"} {
+			if !strings.Contains(string(history), text) {
+				t.Errorf("history of %s is missing %q", m.home, text)
+			}
+		}
+
+		// Files of each machine are never synchronized
+		tracked, _ := runGit(filepath.Join(m.home, ".recall"), "ls-files")
+		for _, name := range syncIgnored {
+			if strings.Contains("\n"+tracked+"\n", "\n"+name+"\n") {
+				t.Errorf("%s is tracked in %s", name, m.home)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// varsField holds the user-defined template variables in the info node of a
+// project
+const varsField = "vars"
+
+// projectVars returns the variables declared under info.vars of a project
+func projectVars(projectData ProjectData) map[string]string {
+	vars := make(map[string]string)
+	info, _ := toStringMap(projectData["info"])
+	declared, _ := toStringMap(info[varsField])
+	for name, value := range declared {
+		if value != nil {
+			vars[name] = fmt.Sprint(value)
+		}
+	}
+	return vars
+}
+
+// projectRoot is the directory containing the local store. Projects of the
+// global store don't belong to a directory, so they have none.
+func projectRoot(location projectLocation) (string, bool) {
+	store, _ := filepath.Abs(location.Store)
+	global, _ := filepath.Abs(globalStoreDir())
+	if store == global {
+		return "", false
+	}
+	return filepath.Dir(store), true
+}
+
+// templateVariables returns the values of the placeholders in the entries of
+// a project: {{project}}, {{project_root}}, {{env.NAME}} and info.vars
+func templateVariables(location projectLocation, projectData ProjectData) map[string]string {
+	values := map[string]string{
+		"project": location.Project,
+	}
+	if root, ok := projectRoot(location); ok {
+		values["project_root"] = root
+	}
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			values["env."+name] = value
+		}
+	}
+	// User variables may override the built-in ones
+	for name, value := range projectVars(projectData) {
+		values[name] = value
+	}
+	return values
+}
+
+// expandKeyData expands the placeholders in every field of data
+func expandKeyData(data KeyData, values map[string]string) KeyData {
+	return KeyData{
+		InfoShort: expandPlaceholders(data.InfoShort, values),
+		InfoLong:  expandPlaceholders(data.InfoLong, values),
+		Example:   expandPlaceholders(data.Example, values),
+	}
+}
+
+// printProjectVars lists the variables declared in info.vars
+func printProjectVars(projectData ProjectData) {
+	vars := projectVars(projectData)
+	if len(vars) == 0 {
+		return
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println()
+	fmt.Printf("\033[1;32m#############################\033[0m\n")
+	fmt.Printf("\033[1;32mVariables:\033[0m\n")
+	for _, name := range names {
+		fmt.Printf("{{%s}} = %s\n", name, vars[name])
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProjectVars(t *testing.T) {
+	projectData := parseTestYAML(t, "info:\n  infoShort: app\n  vars:\n    host: example.com\n    port: 8080\n    debug: true\n    unset:\ndb:\n  infoShort: d\n")
+	want := map[string]string{"host": "example.com", "port": "8080", "debug": "true"}
+	if got := projectVars(projectData); !reflect.DeepEqual(got, want) {
+		t.Errorf("projectVars = %v, want %v", got, want)
+	}
+	if got := projectVars(parseTestYAML(t, "db:\n  infoShort: d\n")); len(got) != 0 {
+		t.Errorf("projectVars without info = %v", got)
+	}
+}
+
+func TestExpandKeyData(t *testing.T) {
+	values := map[string]string{"host": "example.com", "project": "app"}
+	data := KeyData{
+		InfoShort: "Deploys {{project}}",
+		InfoLong:  "Runs on {{host}}, not {{other}}",
+		Example:   "scp build.tar {{host}}:/srv/{{project}}",
+	}
+	want := KeyData{
+		InfoShort: "Deploys app",
+		InfoLong:  "Runs on example.com, not {{other}}",
+		Example:   "scp build.tar example.com:/srv/app",
+	}
+	if got := expandKeyData(data, values); got != want {
+		t.Errorf("expandKeyData = %+v, want %+v", got, want)
+	}
+}
+
+func TestTemplateVariables(t *testing.T) {
+	work := useTempStores(t)
+	t.Setenv("RECALL_TEST", "set")
+	projectData := parseTestYAML(t, "info:\n  vars:\n    host: example.com\n    project: renamed\n")
+
+	local := projectLocation{Store: localStoreDir, Project: "app"}
+	values := templateVariables(local, projectData)
+	root, _ := filepath.EvalSymlinks(values["project_root"])
+	wantRoot, _ := filepath.EvalSymlinks(work)
+	if root != wantRoot {
+		t.Errorf("project_root = %q, want %q", values["project_root"], work)
+	}
+	if values["env.RECALL_TEST"] != "set" || values["host"] != "example.com" {
+		t.Errorf("values = %v", values)
+	}
+	if values["project"] != "renamed" {
+		t.Errorf("project = %q, declared variables override built-in ones", values["project"])
+	}
+
+	// Global projects have no root, so the placeholder stays unexpanded
+	global := projectLocation{Store: globalStoreDir(), Project: "app"}
+	values = templateVariables(global, nil)
+	if _, ok := values["project_root"]; ok {
+		t.Errorf("global project has project_root %q", values["project_root"])
+	}
+	if got := expandPlaceholders("cd {{project_root}}", values); got != "cd {{project_root}}" {
+		t.Errorf("expanded to %q", got)
+	}
+}
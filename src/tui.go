@@ -0,0 +1,806 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+	"golang.org/x/term"
+)
+
+// tabWidth is the number of columns a tab is expanded to on screen
+const tabWidth = 4
+
+// terminal is a terminal in raw mode on which full screen frames are drawn
+type terminal struct {
+	in      *os.File
+	out     *bufio.Writer
+	state   *term.State
+	pending []byte // input read but not decoded yet
+}
+
+func openTerminal() (*terminal, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, fmt.Errorf("interactive mode needs a terminal")
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	t := &terminal{in: os.Stdin, out: bufio.NewWriter(os.Stdout), state: state}
+
+	// Use the alternate screen, so the shell output is back after quitting
+	t.out.WriteString("\x1b[?1049h")
+	t.out.Flush()
+	return t, nil
+}
+
+func (t *terminal) close() {
+	t.out.WriteString("\x1b[?25h\x1b[?1049l")
+	t.out.Flush()
+	term.Restore(int(t.in.Fd()), t.state)
+}
+
+// size returns the width and height of the terminal, read on every frame so
+// resizing just works
+func (t *terminal) size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// screenLine is one line of a frame, with an optional SGR style like "1;32"
+type screenLine struct {
+	text  string
+	style string
+}
+
+// draw replaces the screen with lines and places the cursor at row and col
+// (counted from zero), or hides it if row is negative
+func (t *terminal) draw(lines []screenLine, row, col int) {
+	width, height := t.size()
+	t.out.WriteString("\x1b[?25l\x1b[H")
+	for i := 0; i < height; i++ {
+		if i < len(lines) {
+			text := truncateRunes(expandTabs(lines[i].text), width)
+			if lines[i].style != "" {
+				text = "\x1b[" + lines[i].style + "m" + text + "\x1b[0m"
+			}
+			t.out.WriteString(text)
+		}
+		t.out.WriteString("\x1b[K")
+		if i < height-1 {
+			t.out.WriteString("\r\n")
+		}
+	}
+	if row >= 0 {
+		fmt.Fprintf(t.out, "\x1b[%d;%dH\x1b[?25h", row+1, col+1)
+	}
+	t.out.Flush()
+}
+
+// tuiKey is a key press: either a named key like "up" or a typed rune
+type tuiKey struct {
+	name string
+	r    rune
+}
+
+// escapeKeys maps the escape sequences of special keys (without the ESC)
+var escapeKeys = map[string]string{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[1~": "home", "[4~": "end", "[7~": "home", "[8~": "end",
+	"[3~": "delete", "[5~": "pgup", "[6~": "pgdn",
+}
+
+func (t *terminal) readKey() (tuiKey, error) {
+	if len(t.pending) == 0 {
+		buf := make([]byte, 1024)
+		n, err := t.in.Read(buf)
+		if err != nil {
+			return tuiKey{}, err
+		}
+		t.pending = buf[:n]
+	}
+	key, size := decodeKey(t.pending)
+	t.pending = t.pending[size:]
+	return key, nil
+}
+
+// decodeKey decodes the first key press in b and returns its length in bytes
+func decodeKey(b []byte) (tuiKey, int) {
+	switch b[0] {
+	case 0x1b:
+		if len(b) > 2 && (b[1] == '[' || b[1] == 'O') {
+			for i := 2; i < len(b); i++ {
+				if b[i] >= 0x40 && b[i] <= 0x7e {
+					if name, ok := escapeKeys[string(b[1:i+1])]; ok {
+						return tuiKey{name: name}, i + 1
+					}
+					return tuiKey{name: "unknown"}, i + 1
+				}
+			}
+		}
+		return tuiKey{name: "esc"}, 1
+	case '\r', '\n':
+		return tuiKey{name: "enter"}, 1
+	case 0x7f, 0x08:
+		return tuiKey{name: "backspace"}, 1
+	case '\t':
+		return tuiKey{r: '\t'}, 1
+	case 0x03:
+		return tuiKey{name: "ctrl-c"}, 1
+	case 0x13:
+		return tuiKey{name: "ctrl-s"}, 1
+	}
+	if b[0] < 0x20 {
+		return tuiKey{name: "unknown"}, 1
+	}
+	r, size := utf8.DecodeRune(b)
+	return tuiKey{r: r}, size
+}
+
+// tuiList is a scrollable list that can be narrowed with a fuzzy filter
+type tuiList struct {
+	labels  []string // shown while not filtering
+	texts   []string // matched against the filter, and shown while filtering
+	visible []int    // indices of the entries matching the filter
+	cursor  int      // position in visible
+	offset  int      // first visible entry on screen
+	filter  string
+}
+
+func newTUIList(labels, texts []string) *tuiList {
+	l := &tuiList{labels: labels, texts: texts}
+	l.setFilter("")
+	return l
+}
+
+// setFilter shows the entries matching filter, best matches first
+func (l *tuiList) setFilter(filter string) {
+	type match struct{ index, score int }
+	var matches []match
+	for i, text := range l.texts {
+		if score, ok := fuzzyMatch(filter, text); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].score < matches[b].score })
+
+	l.filter = filter
+	l.visible = l.visible[:0]
+	for _, m := range matches {
+		l.visible = append(l.visible, m.index)
+	}
+	l.cursor, l.offset = 0, 0
+}
+
+// selected returns the index of the entry under the cursor, or -1
+func (l *tuiList) selected() int {
+	if len(l.visible) == 0 {
+		return -1
+	}
+	return l.visible[l.cursor]
+}
+
+// move handles the cursor keys and reports whether key was one of them
+func (l *tuiList) move(key tuiKey, page int) bool {
+	switch key.name {
+	case "up":
+		l.cursor--
+	case "down":
+		l.cursor++
+	case "pgup":
+		l.cursor -= page
+	case "pgdn":
+		l.cursor += page
+	case "home":
+		l.cursor = 0
+	case "end":
+		l.cursor = len(l.visible) - 1
+	default:
+		return false
+	}
+	if l.cursor >= len(l.visible) {
+		l.cursor = len(l.visible) - 1
+	}
+	if l.cursor < 0 {
+		l.cursor = 0
+	}
+	return true
+}
+
+func (l *tuiList) render(height, width int) []screenLine {
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+height {
+		l.offset = l.cursor - height + 1
+	}
+
+	lines := make([]screenLine, 0, height)
+	for i := l.offset; i < len(l.visible) && len(lines) < height; i++ {
+		text := " " + l.labels[l.visible[i]]
+		if l.filter != "" {
+			text = " " + l.texts[l.visible[i]]
+		}
+		if i == l.cursor {
+			lines = append(lines, screenLine{text: padRunes(text, width), style: "7"})
+		} else {
+			lines = append(lines, screenLine{text: text})
+		}
+	}
+	if len(l.visible) == 0 {
+		lines = append(lines, screenLine{text: " (no matches)", style: "2"})
+	}
+	for len(lines) < height {
+		lines = append(lines, screenLine{})
+	}
+	return lines
+}
+
+// handleListKey handles the keys shared by all lists: moving, filtering and
+// quitting. It reports whether key was handled and whether to quit.
+func handleListKey(list *tuiList, filtering *bool, key tuiKey, page int) (bool, bool) {
+	if key.name == "ctrl-c" {
+		return true, true
+	}
+	if list.move(key, page) {
+		return true, false
+	}
+
+	if *filtering {
+		switch key.name {
+		case "enter":
+			// Leave filter mode and let the caller open the selection
+			*filtering = false
+			return false, false
+		case "esc":
+			*filtering = false
+			list.setFilter("")
+		case "backspace":
+			if runes := []rune(list.filter); len(runes) > 0 {
+				list.setFilter(string(runes[:len(runes)-1]))
+			}
+		case "":
+			list.setFilter(list.filter + string(key.r))
+		}
+		return true, false
+	}
+
+	switch {
+	case key.r == '/':
+		*filtering = true
+	case key.r == 'j':
+		list.move(tuiKey{name: "down"}, page)
+	case key.r == 'k':
+		list.move(tuiKey{name: "up"}, page)
+	case key.r == 'q':
+		return true, true
+	case key.name == "esc" && list.filter != "":
+		list.setFilter("")
+	default:
+		return false, false
+	}
+	return true, false
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in text in order,
+// ignoring case. Lower scores are tighter matches.
+func fuzzyMatch(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	first, j := -1, 0
+	for i, r := range []rune(strings.ToLower(text)) {
+		if r != p[j] {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		j++
+		if j == len(p) {
+			return i - first, true
+		}
+	}
+	return 0, false
+}
+
+// statusLine is the bottom line of the browser screens
+func statusLine(filtering bool, filter, message, help string) screenLine {
+	switch {
+	case filtering:
+		return screenLine{text: "/" + filter}
+	case message != "":
+		return screenLine{text: message, style: "1"}
+	case filter != "":
+		return screenLine{text: "filter: " + filter + " (esc clears)  " + help, style: "2"}
+	}
+	return screenLine{text: help, style: "2"}
+}
+
+// interactiveCommand runs the full screen browser, starting with the key tree
+// of project or, without one, the projects of every store
+func interactiveCommand(settings *Settings, project string) {
+	var location projectLocation
+	if project != "" {
+		var err error
+		if location, err = findProjectFile(settings, project); err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			return
+		}
+	}
+
+	t, err := openTerminal()
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	defer t.close()
+
+	if project != "" {
+		browseKeys(t, location)
+		return
+	}
+	browseProjects(t, settings)
+}
+
+func browseProjects(t *terminal, settings *Settings) {
+	type projectEntry struct{ store, name string }
+	var entries []projectEntry
+	var labels, texts []string
+	for _, dir := range storeDirs() {
+		names, err := listProjects(settings, dir, "")
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			entries = append(entries, projectEntry{dir, name})
+			labels = append(labels, fmt.Sprintf("%-32s %s", name, dir))
+			texts = append(texts, name)
+		}
+	}
+
+	list := newTUIList(labels, texts)
+	filtering := false
+	message := ""
+	for {
+		width, height := t.size()
+		lines := []screenLine{{text: "recall: projects", style: "1;32"}}
+		lines = append(lines, list.render(height-2, width)...)
+		lines = append(lines, statusLine(filtering, list.filter, message, "enter open  / filter  q quit"))
+		if filtering {
+			t.draw(lines, height-1, 1+utf8.RuneCountInString(list.filter))
+		} else {
+			t.draw(lines, -1, 0)
+		}
+
+		key, err := t.readKey()
+		if err != nil {
+			return
+		}
+		message = ""
+		handled, quit := handleListKey(list, &filtering, key, height-2)
+		if quit {
+			return
+		}
+		if handled {
+			continue
+		}
+
+		switch {
+		case key.name == "esc":
+			return
+		case key.name == "enter":
+			i := list.selected()
+			if i < 0 {
+				continue
+			}
+			location, err := locateProject(settings, entries[i].store, entries[i].name)
+			if err != nil {
+				message = err.Error()
+				continue
+			}
+			if browseKeys(t, location) {
+				return
+			}
+		}
+	}
+}
+
+// keyTree lists the keys of a project in tree order, starting with the
+// project info
+func keyTree(project string, projectData ProjectData) (paths [][]string, labels, texts []string) {
+	info := getKeyData(projectData, "")
+	paths = append(paths, []string{})
+	labels = append(labels, fmt.Sprintf("%-30s %s", project, singleLine(info.InfoShort)))
+	texts = append(texts, project)
+
+	walkKeys(projectData, func(keyPath []string, data KeyData) {
+		if len(keyPath) == 0 {
+			return // the project info
+		}
+		name := strings.Repeat("  ", len(keyPath)) + keyPath[len(keyPath)-1]
+		paths = append(paths, keyPath)
+		labels = append(labels, fmt.Sprintf("%-30s %s", name, singleLine(data.InfoShort)))
+		texts = append(texts, strings.Join(keyPath, " "))
+	})
+	return paths, labels, texts
+}
+
+// keyPreview renders the fields of a key for the lower half of the screen
+func keyPreview(projectData ProjectData, keyPath []string, width, height int) []screenLine {
+	path := keyPathString(keyPath)
+	data := getKeyData(projectData, path)
+
+	var lines []screenLine
+	section := func(title, text string) {
+		if text == "" {
+			return
+		}
+		lines = append(lines, screenLine{text: title, style: "1;32"})
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, wrapLine(expandTabs(line), width)...)
+		}
+		lines = append(lines, screenLine{})
+	}
+	section("Short:", data.InfoShort)
+	section("Description:", data.InfoLong)
+	section("Example:", data.Example)
+	if len(lines) == 0 {
+		lines = append(lines, screenLine{text: "(empty, press e to edit)", style: "2"})
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+// browseKeys shows the key tree of a project with a preview of the selected
+// key. It reports whether the user asked to quit rather than to go back.
+func browseKeys(t *terminal, location projectLocation) bool {
+	projectData := loadProjectData(location)
+	paths, labels, texts := keyTree(location.Project, projectData)
+	list := newTUIList(labels, texts)
+	filtering := false
+	message := ""
+
+	for {
+		width, height := t.size()
+		listHeight := (height - 3) / 2
+		if listHeight < 1 {
+			listHeight = 1
+		}
+
+		lines := []screenLine{{text: fmt.Sprintf("recall: %s (%s)", location.Project, location.Path), style: "1;32"}}
+		lines = append(lines, list.render(listHeight, width)...)
+		lines = append(lines, screenLine{text: strings.Repeat("─", width), style: "2"})
+		if i := list.selected(); i >= 0 {
+			lines = append(lines, keyPreview(projectData, paths[i], width, height-3-listHeight)...)
+		}
+		for len(lines) < height-1 {
+			lines = append(lines, screenLine{})
+		}
+		lines = append(lines, statusLine(filtering, list.filter, message, "e edit  / filter  esc back  q quit"))
+		if filtering {
+			t.draw(lines, height-1, 1+utf8.RuneCountInString(list.filter))
+		} else {
+			t.draw(lines, -1, 0)
+		}
+
+		key, err := t.readKey()
+		if err != nil {
+			return true
+		}
+		message = ""
+		handled, quit := handleListKey(list, &filtering, key, listHeight)
+		if quit {
+			return true
+		}
+		if handled {
+			continue
+		}
+
+		switch {
+		case key.name == "esc":
+			return false
+		case key.name == "enter" || key.r == 'e':
+			i := list.selected()
+			if i < 0 {
+				continue
+			}
+			message = editKeyField(t, location, projectData, paths[i])
+
+			// Refresh the summaries, keeping the cursor where it is
+			_, labels, _ = keyTree(location.Project, projectData)
+			list.labels = labels
+		}
+	}
+}
+
+// editKeyField asks which field of a key to edit, edits it in place and saves
+// the project. It returns the message to show.
+func editKeyField(t *terminal, location projectLocation, projectData ProjectData, keyPath []string) string {
+	width, height := t.size()
+	lines := make([]screenLine, height-1)
+	lines = append(lines, screenLine{text: padRunes("Edit field: [s]hort  [l]ong  [e]xample  (esc cancels)", width), style: "7"})
+	t.draw(lines, -1, 0)
+
+	key, err := t.readKey()
+	if err != nil {
+		return ""
+	}
+	path := keyPathString(keyPath)
+	data := getKeyData(projectData, path)
+	var field *string
+	var name string
+	switch key.r {
+	case 's':
+		field, name = &data.InfoShort, "infoShort"
+	case 'l':
+		field, name = &data.InfoLong, "infoLong"
+	case 'e':
+		field, name = &data.Example, "example"
+	default:
+		return ""
+	}
+
+	title := fmt.Sprintf("%s of %s", name, strings.Join(append([]string{location.Project}, keyPath...), " "))
+	edited, ok := editText(t, title, *field, 1)
+	edited = strings.TrimSpace(edited)
+	if !ok || edited == *field {
+		return "Nothing changed"
+	}
+	*field = edited
+
+	setKeyData(projectData, path, data)
+	refreshSourceHash(location, projectData, path)
+	if err := saveProjectData(location, projectData); err != nil {
+		return fmt.Sprintf("Error saving %s: %v", location.Path, err)
+	}
+	return fmt.Sprintf("Saved %s to %s", name, location.Path)
+}
+
+// textEditor is a minimal multi-line editor for editing a field in place
+type textEditor struct {
+	lines     [][]rune
+	row, col  int // cursor position, col counted in runes
+	top, left int // scroll position, left counted in columns
+	modified  bool
+}
+
+// editText lets the user edit text on the full screen, starting at line. It
+// returns false if the edit was cancelled.
+func editText(t *terminal, title, text string, line int) (string, bool) {
+	e := &textEditor{}
+	for _, line := range strings.Split(text, "\n") {
+		e.lines = append(e.lines, []rune(line))
+	}
+	e.row = line - 1
+	if e.row >= len(e.lines) {
+		e.row = len(e.lines) - 1
+	}
+	if e.row < 0 {
+		e.row = 0
+	}
+
+	message := ""
+	discard := false
+	for {
+		width, height := t.size()
+		page := height - 2
+		if page < 1 {
+			page = 1
+		}
+
+		// Scroll to the cursor
+		if e.row < e.top {
+			e.top = e.row
+		}
+		if e.row >= e.top+page {
+			e.top = e.row - page + 1
+		}
+		column := utf8.RuneCountInString(expandTabs(string(e.lines[e.row][:e.col])))
+		if column < e.left {
+			e.left = column
+		}
+		if column >= e.left+width {
+			e.left = column - width + 1
+		}
+
+		lines := []screenLine{{text: padRunes("Editing "+title, width), style: "7"}}
+		for i := e.top; i < e.top+page; i++ {
+			if i < len(e.lines) {
+				lines = append(lines, screenLine{text: sliceRunes(expandTabs(string(e.lines[i])), e.left, width)})
+			} else {
+				lines = append(lines, screenLine{text: "~", style: "2"})
+			}
+		}
+		if message == "" {
+			message = "ctrl-s save  esc cancel"
+		}
+		lines = append(lines, screenLine{text: message, style: "2"})
+		t.draw(lines, e.row-e.top+1, column-e.left)
+
+		key, err := t.readKey()
+		if err != nil {
+			return text, false
+		}
+		message = ""
+		switch key.name {
+		case "ctrl-s":
+			return e.text(), true
+		case "esc", "ctrl-c":
+			if !e.modified || discard {
+				return text, false
+			}
+			discard = true
+			message = "Unsaved changes, press esc again to discard them"
+			continue
+		}
+		discard = false
+		e.handleKey(key, page)
+	}
+}
+
+func (e *textEditor) text() string {
+	lines := make([]string, len(e.lines))
+	for i, line := range e.lines {
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *textEditor) handleKey(key tuiKey, page int) {
+	line := e.lines[e.row]
+	switch key.name {
+	case "up":
+		e.row--
+	case "down":
+		e.row++
+	case "pgup":
+		e.row -= page
+	case "pgdn":
+		e.row += page
+	case "home":
+		e.col = 0
+	case "end":
+		e.col = len(line)
+	case "left":
+		if e.col > 0 {
+			e.col--
+		} else if e.row > 0 {
+			e.row--
+			e.col = len(e.lines[e.row])
+		}
+	case "right":
+		if e.col < len(line) {
+			e.col++
+		} else if e.row < len(e.lines)-1 {
+			e.row++
+			e.col = 0
+		}
+	case "enter":
+		rest := append([]rune{}, line[e.col:]...)
+		e.lines[e.row] = line[:e.col]
+		e.lines = append(e.lines[:e.row+1], append([][]rune{rest}, e.lines[e.row+1:]...)...)
+		e.row++
+		e.col = 0
+		e.modified = true
+	case "backspace":
+		if e.col > 0 {
+			e.lines[e.row] = append(line[:e.col-1], line[e.col:]...)
+			e.col--
+		} else if e.row > 0 {
+			previous := e.lines[e.row-1]
+			e.col = len(previous)
+			e.lines[e.row-1] = append(previous, line...)
+			e.lines = append(e.lines[:e.row], e.lines[e.row+1:]...)
+			e.row--
+		} else {
+			return
+		}
+		e.modified = true
+	case "delete":
+		if e.col < len(line) {
+			e.lines[e.row] = append(line[:e.col], line[e.col+1:]...)
+		} else if e.row < len(e.lines)-1 {
+			e.lines[e.row] = append(line, e.lines[e.row+1]...)
+			e.lines = append(e.lines[:e.row+1], e.lines[e.row+2:]...)
+		} else {
+			return
+		}
+		e.modified = true
+	case "":
+		inserted := make([]rune, 0, len(line)+1)
+		inserted = append(append(append(inserted, line[:e.col]...), key.r), line[e.col:]...)
+		e.lines[e.row] = inserted
+		e.col++
+		e.modified = true
+	}
+
+	if e.row < 0 {
+		e.row = 0
+	}
+	if e.row >= len(e.lines) {
+		e.row = len(e.lines) - 1
+	}
+	if e.col > len(e.lines[e.row]) {
+		e.col = len(e.lines[e.row])
+	}
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	column := 0
+	for _, r := range s {
+		if r == '\t' {
+			spaces := tabWidth - column%tabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		b.WriteRune(r)
+		column++
+	}
+	return b.String()
+}
+
+func truncateRunes(s string, width int) string {
+	return sliceRunes(s, 0, width)
+}
+
+// sliceRunes returns at most width runes of s, starting at rune start
+func sliceRunes(s string, start, width int) string {
+	runes := []rune(s)
+	if start >= len(runes) {
+		return ""
+	}
+	runes = runes[start:]
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+	return string(runes)
+}
+
+func padRunes(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// wrapLine breaks a line into screen lines of at most width runes
+func wrapLine(s string, width int) []screenLine {
+	runes := []rune(s)
+	if len(runes) == 0 || width <= 0 {
+		return []screenLine{{}}
+	}
+	var lines []screenLine
+	for len(runes) > 0 {
+		n := width
+		if n > len(runes) {
+			n = len(runes)
+		}
+		lines = append(lines, screenLine{text: string(runes[:n])})
+		runes = runes[n:]
+	}
+	return lines
+}
+
+// singleLine joins the lines of s for display in a list
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		input string
+		key   tuiKey
+		size  int
+	}{
+		{"\x1b[A", tuiKey{name: "up"}, 3},
+		{"\x1bOB", tuiKey{name: "down"}, 3},
+		{"\x1b[3~", tuiKey{name: "delete"}, 4},
+		{"\x1b[6~x", tuiKey{name: "pgdn"}, 4},
+		{"\x1b[1;5C", tuiKey{name: "unknown"}, 6},
+		{"\x1b", tuiKey{name: "esc"}, 1},
+		{"\x1bx", tuiKey{name: "esc"}, 1},
+		{"\x1b[", tuiKey{name: "esc"}, 1},
+		{"\r", tuiKey{name: "enter"}, 1},
+		{"\x7f", tuiKey{name: "backspace"}, 1},
+		{"\t", tuiKey{r: '\t'}, 1},
+		{"\x03", tuiKey{name: "ctrl-c"}, 1},
+		{"\x13", tuiKey{name: "ctrl-s"}, 1},
+		{"\x01", tuiKey{name: "unknown"}, 1},
+		{"ab", tuiKey{r: 'a'}, 1},
+		{"é!", tuiKey{r: 'é'}, 2},
+		{"世", tuiKey{r: '世'}, 3},
+	}
+	for _, tt := range tests {
+		key, size := decodeKey([]byte(tt.input))
+		if key != tt.key || size != tt.size {
+			t.Errorf("decodeKey(%q) = %+v, %d, want %+v, %d", tt.input, key, size, tt.key, tt.size)
+		}
+	}
+
+	// Several keys read at once are decoded one after the other
+	var names []string
+	for b := []byte("\x1b[Aq\x1b\r"); len(b) > 0; {
+		key, size := decodeKey(b)
+		if key.name == "" {
+			key.name = string(key.r)
+		}
+		names = append(names, key.name)
+		b = b[size:]
+	}
+	if got := strings.Join(names, " "); got != "up q esc enter" {
+		t.Errorf("decoded %s", got)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		score         int
+		ok            bool
+	}{
+		{"", "anything", 0, true},
+		{"db", "db", 1, true},
+		{"DB", "database", 4, true},
+		{"dbp", "db pool", 3, true},
+		{"pd", "db pool", 0, false},
+		{"ü", "Über", 0, true},
+		{"xyz", "xy", 0, false},
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyMatch(tt.pattern, tt.text)
+		if score != tt.score || ok != tt.ok {
+			t.Errorf("fuzzyMatch(%q, %q) = %d, %v, want %d, %v", tt.pattern, tt.text, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+func TestTUIList(t *testing.T) {
+	texts := []string{"app db", "app db pool", "app deploy", "app cache"}
+	list := newTUIList(texts, texts)
+	if len(list.visible) != 4 || list.selected() != 0 {
+		t.Fatalf("visible %v, selected %d", list.visible, list.selected())
+	}
+
+	// Tighter matches come first, ties keep their order
+	list.setFilter("dp")
+	if got := list.visible; len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Errorf("filter dp shows %v, want [2 1]", got)
+	}
+	list.setFilter("zzz")
+	if list.selected() != -1 || list.move(tuiKey{name: "down"}, 1) != true || list.selected() != -1 {
+		t.Errorf("empty list selects %d", list.selected())
+	}
+
+	list.setFilter("")
+	moves := []struct {
+		key  string
+		want int
+	}{
+		{"up", 0}, {"down", 1}, {"pgdn", 3}, {"down", 3}, {"pgup", 1}, {"home", 0}, {"end", 3},
+	}
+	for _, m := range moves {
+		if !list.move(tuiKey{name: m.key}, 2) {
+			t.Errorf("%s is not a cursor key", m.key)
+		}
+		if list.selected() != m.want {
+			t.Errorf("after %s selected %d, want %d", m.key, list.selected(), m.want)
+		}
+	}
+	if list.move(tuiKey{r: 'x'}, 2) {
+		t.Error("x moved the cursor")
+	}
+}
+
+// editorWith returns a textEditor holding text with the cursor at row, col
+func editorWith(text string, row, col int) *textEditor {
+	e := &textEditor{row: row, col: col}
+	for _, line := range strings.Split(text, "\n") {
+		e.lines = append(e.lines, []rune(line))
+	}
+	return e
+}
+
+func TestTextEditorHandleKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		row, col int
+		keys     []tuiKey
+		want     string
+		wantRow  int
+		wantCol  int
+	}{
+		{"insert multibyte", "über", 0, 1, []tuiKey{{r: '世'}}, "ü世ber", 0, 2},
+		{"insert tab", "a\tb", 0, 2, []tuiKey{{r: '\t'}}, "a\t\tb", 0, 3},
+		{"backspace multibyte", "日本語", 0, 2, []tuiKey{{name: "backspace"}}, "日語", 0, 1},
+		{"backspace joins lines", "ab\ncd", 1, 0, []tuiKey{{name: "backspace"}}, "abcd", 0, 2},
+		{"delete joins lines", "é\nx", 0, 1, []tuiKey{{name: "delete"}}, "éx", 0, 1},
+		{"enter splits", "a\tb", 0, 2, []tuiKey{{name: "enter"}}, "a\t\nb", 1, 0},
+		{"left wraps", "ab\ncd", 1, 0, []tuiKey{{name: "left"}}, "ab\ncd", 0, 2},
+		{"right wraps", "ab\ncd", 0, 2, []tuiKey{{name: "right"}}, "ab\ncd", 1, 0},
+		{"down clamps the column", "long line\nab", 0, 9, []tuiKey{{name: "down"}}, "long line\nab", 1, 2},
+		{"pgdn clamps the row", "a\nb\nc", 0, 0, []tuiKey{{name: "pgdn"}}, "a\nb\nc", 2, 0},
+		{"end counts runes", "äöü", 0, 0, []tuiKey{{name: "end"}}, "äöü", 0, 3},
+	}
+	for _, tt := range tests {
+		e := editorWith(tt.text, tt.row, tt.col)
+		for _, key := range tt.keys {
+			e.handleKey(key, 5)
+		}
+		if e.text() != tt.want || e.row != tt.wantRow || e.col != tt.wantCol {
+			t.Errorf("%s: %q at %d:%d, want %q at %d:%d", tt.name, e.text(), e.row, e.col, tt.want, tt.wantRow, tt.wantCol)
+		}
+		if e.modified != (tt.want != tt.text) {
+			t.Errorf("%s: modified = %v", tt.name, e.modified)
+		}
+	}
+
+	// Nothing to delete at the edges of the text
+	e := editorWith("x", 0, 0)
+	e.handleKey(tuiKey{name: "backspace"}, 5)
+	e.col = 1
+	e.handleKey(tuiKey{name: "delete"}, 5)
+	if e.text() != "x" || e.modified {
+		t.Errorf("edges: %q, modified %v", e.text(), e.modified)
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	tests := map[string]string{
+		"no tabs": "no tabs",
+		"\tx":     "    x",
+		"ab\tx":   "ab  x",
+		"abcd\tx": "abcd    x",
+		"ü\tx":    "ü   x",
+		"a\t\tx":  "a       x",
+	}
+	for input, want := range tests {
+		if got := expandTabs(input); got != want {
+			t.Errorf("expandTabs(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestKeyTree(t *testing.T) {
+	projectData := parseTestYAML(t, "info:\n  infoShort: the app\nzeta:\n  infoShort: last\ndb:\n  infoShort: |-\n    two\n    lines\n  keys:\n    pool:\n      infoShort: p\n")
+	paths, labels, texts := keyTree("app", projectData)
+
+	var got []string
+	for _, path := range paths {
+		got = append(got, strings.Join(path, "."))
+	}
+	if strings.Join(got, " ") != " db db.pool zeta" {
+		t.Fatalf("paths %q", got)
+	}
+	if strings.Join(texts, "|") != "app|db|db pool|zeta" {
+		t.Errorf("texts %q", texts)
+	}
+	if !strings.HasPrefix(labels[0], "app ") || !strings.HasSuffix(labels[0], " the app") {
+		t.Errorf("project label %q", labels[0])
+	}
+	if !strings.HasPrefix(labels[2], "    pool ") || !strings.HasSuffix(labels[1], " two lines") {
+		t.Errorf("key labels %q", labels[1:])
+	}
+}
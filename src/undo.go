@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"gopkg.in/yaml.v2"
+)
+
+// undoJournalDepth is the number of writes that can be undone
+const undoJournalDepth = 20
+
+// undoEntry is the state of a project before one write made by recall
+type undoEntry struct {
+	Time    string      `yaml:"time"`
+	Store   string      `yaml:"store"`   // absolute store directory
+	Backend string      `yaml:"backend"` // storage backend name
+	Project string      `yaml:"project"`
+	Data    ProjectData `yaml:"data,omitempty"` // empty if the project didn't exist
+}
+
+// undoJournalDir holds one file per undoable write. It is shared by all
+// stores, so --undo works from anywhere, and lives in the user's cache
+// directory, outside of any store that may be synchronized or committed.
+func undoJournalDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		homeDir, _ := os.UserHomeDir()
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheDir, "recall", "undo")
+}
+
+// undoJournalFiles lists the entries of the journal, oldest first
+func undoJournalFiles() ([]string, error) {
+	files, err := ioutil.ReadDir(undoJournalDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".yaml") {
+			names = append(names, filepath.Join(undoJournalDir(), file.Name()))
+		}
+	}
+	// Names are zero-padded timestamps, so they sort by age
+	sort.Strings(names)
+	return names, nil
+}
+
+func loadUndoEntry(path string) (undoEntry, error) {
+	var entry undoEntry
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+	if err := yaml.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	return entry, nil
+}
+
+// recordUndo adds the state of a project before a write to the journal. Only
+// the new entry is written; entries beyond undoJournalDepth are removed.
+func recordUndo(location projectLocation, previous ProjectData) error {
+	store, err := filepath.Abs(location.Store)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(undoEntry{
+		Time:    time.Now().Format(time.RFC3339),
+		Store:   store,
+		Backend: backendName(location.Backend),
+		Project: location.Project,
+		Data:    previous,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(undoJournalDir(), 0700); err != nil {
+		return err
+	}
+	// Never replace an entry written in the same instant
+	var file *os.File
+	for stamp := time.Now().UnixNano(); ; stamp++ {
+		file, err = os.OpenFile(filepath.Join(undoJournalDir(), fmt.Sprintf("%020d.yaml", stamp)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if !os.IsExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	files, err := undoJournalFiles()
+	if err != nil {
+		return err
+	}
+	for len(files) > undoJournalDepth {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// undoCommand reverts the most recent write made by recall, in any project
+// of any store
+func undoCommand(settings *Settings) {
+	files, err := undoJournalFiles()
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	if len(files) == 0 {
+		fmt.Println("[INFO] Nothing to undo")
+		return
+	}
+	entryFile := files[len(files)-1]
+	entry, err := loadUndoEntry(entryFile)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+
+	backend, ok := storageBackends[entry.Backend]
+	if !ok {
+		fmt.Printf("[ERROR] Unknown storage backend '%s' in %s\n", entry.Backend, entryFile)
+		return
+	}
+	path, err := backend.projectPath(entry.Store, entry.Project)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		return
+	}
+	location := projectLocation{
+		Store:      entry.Store,
+		Project:    entry.Project,
+		Path:       path,
+		Backend:    backend,
+		AutoCommit: settings.GitAutoCommit,
+	}
+
+	// Undoing the creation of a project removes it again
+	var data ProjectData
+	if len(entry.Data) > 0 {
+		data = entry.Data
+	}
+	if err := storeProjectData(location, data, false); err != nil {
+		fmt.Printf("[ERROR] Could not restore %s: %v\n", path, err)
+		return
+	}
+	if err := os.Remove(entryFile); err != nil {
+		fmt.Printf("[WARN] Could not remove %s: %v\n", entryFile, err)
+	}
+
+	if data == nil {
+		fmt.Printf("[INFO] Undid the creation of project '%s' (%s, %s)\n", entry.Project, path, entry.Time)
+	} else {
+		fmt.Printf("[INFO] Restored project '%s' to its state before %s (%s)\n", entry.Project, entry.Time, path)
+	}
+}
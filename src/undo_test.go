@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUndoJournalLivesOutsideTheStores(t *testing.T) {
+	work := useTempStores(t)
+	location, err := findProjectFile(defaultSettings(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"one", "two", "three"} {
+		projectData := loadProjectData(location)
+		setKeyData(projectData, "db", KeyData{InfoShort: text})
+		if err := saveProjectData(location, projectData); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := undoJournalFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("journal has %d entries, want 3", len(files))
+	}
+	for _, store := range []string{globalStoreDir(), filepath.Join(work, localStoreDir)} {
+		if strings.HasPrefix(files[0], store) {
+			t.Errorf("journal %s is inside the store %s", files[0], store)
+		}
+	}
+
+	// Saving only writes the new entry
+	before, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectData := loadProjectData(location)
+	setKeyData(projectData, "db", KeyData{InfoShort: "four"})
+	if err := saveProjectData(location, projectData); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Error("an older entry was rewritten")
+	}
+
+	for _, want := range []string{"three", "two"} {
+		captureOutput(t, func() { undoCommand(defaultSettings()) })
+		if got := getKeyData(loadProjectData(location), "db").InfoShort; got != want {
+			t.Errorf("after undo db = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestUndoJournalDepth(t *testing.T) {
+	useTempStores(t)
+	location, err := findProjectFile(defaultSettings(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < undoJournalDepth+5; i++ {
+		if err := recordUndo(location, ProjectData{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := undoJournalFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != undoJournalDepth {
+		t.Errorf("journal has %d entries, want %d", len(files), undoJournalDepth)
+	}
+}